@@ -162,6 +162,9 @@ type ServiceCheck struct {
 	Header        map[string][]string
 	Method        string
 	CheckRestart  *CheckRestart `mapstructure:"check_restart"`
+	Notes         string
+
+	DeregisterCriticalServiceAfter time.Duration `mapstructure:"deregister_critical_service_after"`
 }
 
 // The Service model represents a Consul service definition
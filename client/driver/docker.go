@@ -17,6 +17,7 @@ import (
 	"time"
 
 	"github.com/armon/circbuf"
+	metrics "github.com/armon/go-metrics"
 	docker "github.com/fsouza/go-dockerclient"
 
 	"github.com/docker/docker/cli/config/configfile"
@@ -111,6 +112,10 @@ const (
 	// dockerImageResKey is the CreatedResources key for docker images
 	dockerImageResKey = "image"
 
+	// dockerLogStatsInterval is the interval at which the statistics of the
+	// syslog collector are retrieved from the executor
+	dockerLogStatsInterval = 10 * time.Second
+
 	// dockerAuthHelperPrefix is the prefix to attach to the credential helper
 	// and should be found in the $PATH. Example: ${prefix-}${helper-name}
 	dockerAuthHelperPrefix = "docker-credential-"
@@ -463,6 +468,7 @@ type DockerHandle struct {
 	Image             string
 	ImageID           string
 	containerID       string
+	taskName          string
 	version           string
 	killTimeout       time.Duration
 	maxKillTimeout    time.Duration
@@ -835,6 +841,7 @@ func (d *DockerDriver) Start(ctx *ExecContext, task *structs.Task) (*StartRespon
 		Image:          d.driverConfig.ImageName,
 		ImageID:        d.imageID,
 		containerID:    container.ID,
+		taskName:       d.taskName,
 		version:        d.config.Version.VersionNumber(),
 		killTimeout:    GetKillTimeout(task.KillTimeout, maxKill),
 		maxKillTimeout: maxKill,
@@ -842,6 +849,9 @@ func (d *DockerDriver) Start(ctx *ExecContext, task *structs.Task) (*StartRespon
 		waitCh:         make(chan *dstructs.WaitResult, 1),
 	}
 	go h.collectStats()
+	if syslogAddr != "" {
+		go h.collectLogStats(false)
+	}
 	go h.run()
 
 	// Detect container address
@@ -1647,6 +1657,7 @@ func (d *DockerDriver) Open(ctx *ExecContext, handleID string) (DriverHandle, er
 		Image:          pid.Image,
 		ImageID:        pid.ImageID,
 		containerID:    pid.ContainerID,
+		taskName:       d.taskName,
 		version:        pid.Version,
 		killTimeout:    pid.KillTimeout,
 		maxKillTimeout: pid.MaxKillTimeout,
@@ -1654,6 +1665,7 @@ func (d *DockerDriver) Open(ctx *ExecContext, handleID string) (DriverHandle, er
 		waitCh:         make(chan *dstructs.WaitResult, 1),
 	}
 	go h.collectStats()
+	go h.collectLogStats(true)
 	go h.run()
 	return h, nil
 }
@@ -1884,6 +1896,62 @@ func (h *DockerHandle) collectStats() {
 	}
 }
 
+// collectLogStats periodically retrieves the statistics of the syslog
+// collector running in the executor and emits them as metrics. The syslog
+// server runs in the executor's process, which has no metrics sink of its own.
+// When re-attaching, failures counted before are assumed to have been emitted
+// by the prior handle.
+func (h *DockerHandle) collectLogStats(reattached bool) {
+	labels := []metrics.Label{
+		{Name: "driver", Value: "docker"},
+		{Name: "task", Value: h.taskName},
+	}
+
+	var last uint64
+	if reattached {
+		if stats, err := h.executor.SyslogServerStats(); err == nil {
+			last = stats.ParseFailures
+		}
+	}
+
+	ticker := time.NewTicker(dockerLogStatsInterval)
+	defer ticker.Stop()
+	failing := false
+	for {
+		select {
+		case <-ticker.C:
+		case <-h.doneCh:
+			return
+		}
+
+		next, err := h.emitLogStats(last, labels)
+		if err != nil {
+			// Only log the first of consecutive failures
+			if !failing {
+				h.logger.Printf("[DEBUG] driver.docker: error collecting log stats for container %s: %v", h.containerID, err)
+			}
+			failing = true
+			continue
+		}
+		failing = false
+		last = next
+	}
+}
+
+// emitLogStats emits the parse failures counted by the executor's syslog
+// server since last and returns the current count.
+func (h *DockerHandle) emitLogStats(last uint64, labels []metrics.Label) (uint64, error) {
+	stats, err := h.executor.SyslogServerStats()
+	if err != nil {
+		return last, err
+	}
+	if stats.ParseFailures > last {
+		metrics.IncrCounterWithLabels([]string{"client", "logcollector", "parse_failure"},
+			float32(stats.ParseFailures-last), labels)
+	}
+	return stats.ParseFailures, nil
+}
+
 func calculatePercent(newSample, oldSample, newTotal, oldTotal uint64, cores int) float64 {
 	numerator := newSample - oldSample
 	denom := newTotal - oldTotal
@@ -15,12 +15,14 @@ import (
 	"testing"
 	"time"
 
+	metrics "github.com/armon/go-metrics"
 	docker "github.com/fsouza/go-dockerclient"
 	"github.com/hashicorp/consul/lib/freeport"
 	sockaddr "github.com/hashicorp/go-sockaddr"
 	"github.com/hashicorp/nomad/client/allocdir"
 	"github.com/hashicorp/nomad/client/config"
 	"github.com/hashicorp/nomad/client/driver/env"
+	"github.com/hashicorp/nomad/client/driver/executor"
 	cstructs "github.com/hashicorp/nomad/client/structs"
 	"github.com/hashicorp/nomad/client/testutil"
 	"github.com/hashicorp/nomad/helper/uuid"
@@ -2247,3 +2249,43 @@ func TestDockerDriver_Kill(t *testing.T) {
 	err = handle.Kill()
 	assert.Nil(err)
 }
+
+// syslogStatsExecutor is an Executor that only implements SyslogServerStats
+type syslogStatsExecutor struct {
+	executor.Executor
+	parseFailures uint64
+}
+
+func (e *syslogStatsExecutor) SyslogServerStats() (*executor.SyslogServerStats, error) {
+	return &executor.SyslogServerStats{ParseFailures: e.parseFailures}, nil
+}
+
+func TestDockerHandle_EmitLogStats(t *testing.T) {
+	sink := metrics.NewInmemSink(time.Hour, time.Hour)
+	conf := metrics.DefaultConfig("")
+	conf.EnableHostname = false
+	metrics.NewGlobal(conf, sink)
+
+	exec := &syslogStatsExecutor{parseFailures: 3}
+	h := &DockerHandle{executor: exec, taskName: "web"}
+	labels := []metrics.Label{
+		{Name: "driver", Value: "docker"},
+		{Name: "task", Value: "web"},
+	}
+	key := "client.logcollector.parse_failure;driver=docker;task=web"
+
+	last, err := h.emitLogStats(0, labels)
+	require.NoError(t, err)
+	require.EqualValues(t, 3, last)
+
+	// Only failures since the last poll are emitted
+	exec.parseFailures = 5
+	last, err = h.emitLogStats(last, labels)
+	require.NoError(t, err)
+	require.EqualValues(t, 5, last)
+
+	counter, ok := sink.Data()[0].Counters[key]
+	require.True(t, ok, "expected counter %q, got: %#v", key, sink.Data()[0].Counters)
+	require.Equal(t, 2, counter.Count)
+	require.EqualValues(t, 5, counter.Sum)
+}
@@ -51,6 +51,7 @@ type Executor interface {
 	SetContext(ctx *ExecutorContext) error
 	LaunchCmd(command *ExecCommand) (*ProcessState, error)
 	LaunchSyslogServer() (*SyslogServerState, error)
+	SyslogServerStats() (*SyslogServerStats, error)
 	Wait() (*ProcessState, error)
 	ShutDown() error
 	Exit() error
@@ -136,6 +137,12 @@ type SyslogServerState struct {
 	Addr            string
 }
 
+// SyslogServerStats holds the counters of a launched syslog server
+type SyslogServerStats struct {
+	// ParseFailures is the number of received lines that could not be parsed
+	ParseFailures uint64
+}
+
 // ExecutorVersion is the version of the executor
 type ExecutorVersion struct {
 	Version string
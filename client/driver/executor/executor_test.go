@@ -1,8 +1,10 @@
 package executor
 
 import (
+	"fmt"
 	"io/ioutil"
 	"log"
+	"net"
 	"os"
 	"path/filepath"
 	"strings"
@@ -250,6 +252,47 @@ func TestExecutor_MakeExecutable(t *testing.T) {
 	}
 }
 
+func TestExecutor_SyslogServerStats(t *testing.T) {
+	t.Parallel()
+	ctx, allocDir := testExecutorContext(t)
+	defer allocDir.Destroy()
+	executor := NewExecutor(log.New(os.Stdout, "", log.LstdFlags))
+	defer executor.Exit()
+
+	if _, err := executor.SyslogServerStats(); err == nil {
+		t.Fatalf("expected an error before the syslog server is launched")
+	}
+
+	if err := executor.SetContext(ctx); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	ss, err := executor.LaunchSyslogServer()
+	if err != nil {
+		t.Fatalf("error launching syslog server: %v", err)
+	}
+
+	parts := strings.SplitN(ss.Addr, "://", 2)
+	conn, err := net.Dial(parts[0], parts[1])
+	if err != nil {
+		t.Fatalf("error connecting to syslog server: %v", err)
+	}
+	defer conn.Close()
+	fmt.Fprint(conn, "<30>2016-02-10T10:16:43-08:00 d-thinkpad docker/e2a1e3ebd3a3[22950]: ok\nmalformed\n")
+
+	tu.WaitForResult(func() (bool, error) {
+		stats, err := executor.SyslogServerStats()
+		if err != nil {
+			return false, err
+		}
+		if stats.ParseFailures != 1 {
+			return false, fmt.Errorf("expected 1 parse failure, got: %d", stats.ParseFailures)
+		}
+		return true, nil
+	}, func(err error) {
+		t.Fatalf("err: %v", err)
+	})
+}
+
 func TestScanPids(t *testing.T) {
 	t.Parallel()
 	p1 := NewFakeProcess(2, 5)
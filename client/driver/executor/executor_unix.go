@@ -34,6 +34,14 @@ func (e *UniversalExecutor) LaunchSyslogServer() (*SyslogServerState, error) {
 	return &SyslogServerState{Addr: syslogAddr}, nil
 }
 
+// SyslogServerStats returns the statistics of the launched syslog server
+func (e *UniversalExecutor) SyslogServerStats() (*SyslogServerStats, error) {
+	if e.syslogServer == nil {
+		return nil, fmt.Errorf("syslog server not launched")
+	}
+	return &SyslogServerStats{ParseFailures: e.syslogServer.ParseFailures()}, nil
+}
+
 func (e *UniversalExecutor) collectLogs(we io.Writer, wo io.Writer) {
 	for logParts := range e.syslogChan {
 		// If the severity of the log line is err then we write to stderr
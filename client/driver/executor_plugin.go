@@ -57,6 +57,12 @@ func (e *ExecutorRPC) LaunchSyslogServer() (*executor.SyslogServerState, error)
 	return ss, err
 }
 
+func (e *ExecutorRPC) SyslogServerStats() (*executor.SyslogServerStats, error) {
+	var ss *executor.SyslogServerStats
+	err := e.client.Call("Plugin.SyslogServerStats", new(interface{}), &ss)
+	return ss, err
+}
+
 func (e *ExecutorRPC) Wait() (*executor.ProcessState, error) {
 	var ps executor.ProcessState
 	err := e.client.Call("Plugin.Wait", new(interface{}), &ps)
@@ -138,6 +144,14 @@ func (e *ExecutorRPCServer) LaunchSyslogServer(args interface{}, ss *executor.Sy
 	return err
 }
 
+func (e *ExecutorRPCServer) SyslogServerStats(args interface{}, ss *executor.SyslogServerStats) error {
+	stats, err := e.Impl.SyslogServerStats()
+	if stats != nil {
+		*ss = *stats
+	}
+	return err
+}
+
 func (e *ExecutorRPCServer) Wait(args interface{}, ps *executor.ProcessState) error {
 	state, err := e.Impl.Wait()
 	if state != nil {
@@ -0,0 +1,9 @@
+// +build darwin dragonfly freebsd linux netbsd openbsd solaris windows
+
+package logging
+
+// LogParser parses a single log line into a SyslogMessage
+type LogParser interface {
+	// Parse parses a log line. Nil is returned if the line should be skipped.
+	Parse(line []byte) *SyslogMessage
+}
@@ -0,0 +1,66 @@
+// +build darwin dragonfly freebsd linux netbsd openbsd solaris windows
+
+package logging
+
+import (
+	"bufio"
+)
+
+// StreamParser parses log messages from a stream that is read in arbitrary
+// chunks, such as reads from a socket. Partial messages are buffered until
+// the rest of the message arrives. Messages may be newline delimited or
+// octet-counted, as accepted by ScanSyslogFrames.
+type StreamParser struct {
+	parser LogParser
+	buf    []byte
+}
+
+// NewStreamParser creates a StreamParser that parses each complete message
+// with the given parser
+func NewStreamParser(parser LogParser) *StreamParser {
+	return &StreamParser{parser: parser}
+}
+
+// Feed appends a chunk read from the stream and calls fn with each message
+// completed by it. An incomplete trailing message is carried over to the
+// next call. On error the buffered data is discarded.
+func (s *StreamParser) Feed(data []byte, fn func(*SyslogMessage)) error {
+	s.buf = append(s.buf, data...)
+	return s.scan(false, fn)
+}
+
+// Flush parses any buffered partial message as if the stream had ended and
+// calls fn with it
+func (s *StreamParser) Flush(fn func(*SyslogMessage)) error {
+	return s.scan(true, fn)
+}
+
+// scan parses the complete messages in the buffer and keeps the remainder
+func (s *StreamParser) scan(atEOF bool, fn func(*SyslogMessage)) error {
+	off := 0
+	for off < len(s.buf) {
+		advance, token, err := ScanSyslogFrames(s.buf[off:], atEOF)
+		if err != nil {
+			s.buf = s.buf[:0]
+			return err
+		}
+		if advance == 0 {
+			break
+		}
+		off += advance
+		if token == nil {
+			continue
+		}
+		if msg := s.parser.Parse(token); msg != nil {
+			fn(msg)
+		}
+	}
+
+	// Carry the partial message over, bounding it like a bufio.Scanner
+	s.buf = append(s.buf[:0], s.buf[off:]...)
+	if len(s.buf) > bufio.MaxScanTokenSize {
+		s.buf = s.buf[:0]
+		return bufio.ErrTooLong
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+// +build darwin dragonfly freebsd linux netbsd openbsd solaris
+
+package logging
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestStreamParser_Feed(t *testing.T) {
+	t.Parallel()
+	d := NewDockerLogParser(log.New(os.Stdout, "", log.LstdFlags))
+	s := NewStreamParser(d)
+
+	var msgs []*SyslogMessage
+	collect := func(m *SyslogMessage) { msgs = append(msgs, m) }
+
+	// A line split across two reads is emitted once it is complete
+	if err := s.Feed([]byte("<30>2016-02-10T10:16:43-08:00 d-thinkpad docker/e2a1e3ebd3a3[22950]: hello "), collect); err != nil {
+		t.Fatalf("got an err: %v", err)
+	}
+	if len(msgs) != 0 {
+		t.Fatalf("expected no messages from a partial line, got: %d", len(msgs))
+	}
+	if err := s.Feed([]byte("world\n<27>2016-02-10T10:16:44-08:00 d-thinkpad docker/e2a1e3ebd3a3[22950]: sec"), collect); err != nil {
+		t.Fatalf("got an err: %v", err)
+	}
+	if len(msgs) != 1 || string(msgs[0].Message) != "hello world" {
+		t.Fatalf("unexpected messages: %#v", msgs)
+	}
+
+	// Octet-counted frames are carried over as well
+	frame := "<28>2016-02-10T10:16:45-08:00 d-thinkpad docker/e2a1e3ebd3a3[22950]: framed"
+	if err := s.Feed([]byte(fmt.Sprintf("ond\n%d %s", len(frame), frame[:10])), collect); err != nil {
+		t.Fatalf("got an err: %v", err)
+	}
+	if err := s.Feed([]byte(frame[10:]), collect); err != nil {
+		t.Fatalf("got an err: %v", err)
+	}
+	if len(msgs) != 3 {
+		t.Fatalf("expected 3 messages, got: %d", len(msgs))
+	}
+	if string(msgs[1].Message) != "second" || string(msgs[2].Message) != "framed" {
+		t.Fatalf("unexpected messages: %q, %q", msgs[1].Message, msgs[2].Message)
+	}
+
+	// Flushing emits an unterminated trailing line
+	if err := s.Feed([]byte("<30>2016-02-10T10:16:46-08:00 d-thinkpad docker/e2a1e3ebd3a3[22950]: last"), collect); err != nil {
+		t.Fatalf("got an err: %v", err)
+	}
+	if err := s.Flush(collect); err != nil {
+		t.Fatalf("got an err: %v", err)
+	}
+	if len(msgs) != 4 || string(msgs[3].Message) != "last" {
+		t.Fatalf("unexpected messages: %#v", msgs)
+	}
+}
+
+func TestStreamParser_Errors(t *testing.T) {
+	t.Parallel()
+	d := NewDockerLogParser(log.New(os.Stdout, "", log.LstdFlags))
+	s := NewStreamParser(d)
+	noop := func(*SyslogMessage) {}
+
+	// A frame cut short by the end of the stream
+	if err := s.Feed([]byte("50 <30>short"), noop); err != nil {
+		t.Fatalf("got an err: %v", err)
+	}
+	if err := s.Flush(noop); err != ErrBadFrameLength {
+		t.Fatalf("expected err: %v, got: %v", ErrBadFrameLength, err)
+	}
+
+	if err := s.Feed([]byte(strings.Repeat("a", bufio.MaxScanTokenSize+1)), noop); err != bufio.ErrTooLong {
+		t.Fatalf("expected err: %v, got: %v", bufio.ErrTooLong, err)
+	}
+
+	// The parser recovers once the bad data is discarded
+	var msgs []*SyslogMessage
+	if err := s.Feed([]byte("<30>2016-02-10T10:16:43-08:00 d-thinkpad docker/e2a1e3ebd3a3[22950]: ok\n"), func(m *SyslogMessage) { msgs = append(msgs, m) }); err != nil {
+		t.Fatalf("got an err: %v", err)
+	}
+	if len(msgs) != 1 || string(msgs[0].Message) != "ok" {
+		t.Fatalf("unexpected messages: %#v", msgs)
+	}
+}
@@ -0,0 +1,159 @@
+// +build darwin dragonfly freebsd linux netbsd openbsd solaris windows
+
+package logging
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+	"unicode/utf8"
+
+	syslog "github.com/RackSec/srslog"
+)
+
+// severityNames maps syslog severities to their names
+var severityNames = [...]string{
+	syslog.LOG_EMERG:   "emerg",
+	syslog.LOG_ALERT:   "alert",
+	syslog.LOG_CRIT:    "crit",
+	syslog.LOG_ERR:     "error",
+	syslog.LOG_WARNING: "warning",
+	syslog.LOG_NOTICE:  "notice",
+	syslog.LOG_INFO:    "info",
+	syslog.LOG_DEBUG:   "debug",
+}
+
+// facilityNames maps the RFC 3164 facility codes to their names
+var facilityNames = [...]string{
+	"kern",
+	"user",
+	"mail",
+	"daemon",
+	"auth",
+	"syslog",
+	"lpr",
+	"news",
+	"uucp",
+	"cron",
+	"authpriv",
+	"ftp",
+	"ntp",
+	"security",
+	"console",
+	"solaris-cron",
+	"local0",
+	"local1",
+	"local2",
+	"local3",
+	"local4",
+	"local5",
+	"local6",
+	"local7",
+}
+
+// FacilityName returns the name of the message's facility
+func (m *SyslogMessage) FacilityName() string {
+	if m.Facility < 0 || int(m.Facility) >= len(facilityNames) {
+		return "unknown"
+	}
+	return facilityNames[m.Facility]
+}
+
+// SeverityName returns the name of the message's severity
+func (m *SyslogMessage) SeverityName() string {
+	if m.Severity < 0 || int(m.Severity) >= len(severityNames) {
+		return "unknown"
+	}
+	return severityNames[m.Severity]
+}
+
+// messageEncodingBase64 marks a JSON encoded message whose bytes were not
+// valid UTF-8 and were base64 encoded instead
+const messageEncodingBase64 = "base64"
+
+// syslogMessageJSON is the JSON representation of a SyslogMessage
+type syslogMessageJSON struct {
+	Message         string
+	MessageEncoding string `json:",omitempty"`
+	Severity        syslog.Priority
+	SeverityName    string
+	Facility        syslog.Priority
+	FacilityName    string
+	Hostname        string     `json:",omitempty"`
+	Tag             string     `json:",omitempty"`
+	PID             int        `json:",omitempty"`
+	Timestamp       *time.Time `json:",omitempty"`
+	Partial         bool       `json:",omitempty"`
+	Truncated       bool       `json:",omitempty"`
+	Error           string     `json:",omitempty"`
+}
+
+// MarshalJSON encodes the message as a string, falling back to base64 if it
+// is not valid UTF-8, and includes the severity's name.
+func (m *SyslogMessage) MarshalJSON() ([]byte, error) {
+	out := syslogMessageJSON{
+		Severity:     m.Severity,
+		SeverityName: m.SeverityName(),
+		Facility:     m.Facility,
+		FacilityName: m.FacilityName(),
+		Hostname:     m.Hostname,
+		Tag:          m.Tag,
+		PID:          m.PID,
+		Partial:      m.Partial,
+		Truncated:    m.Truncated,
+	}
+	if utf8.Valid(m.Message) {
+		out.Message = string(m.Message)
+	} else {
+		out.Message = base64.StdEncoding.EncodeToString(m.Message)
+		out.MessageEncoding = messageEncodingBase64
+	}
+	if !m.Timestamp.IsZero() {
+		ts := m.Timestamp
+		out.Timestamp = &ts
+	}
+	if m.Err != nil {
+		out.Error = m.Err.Error()
+	}
+	return json.Marshal(&out)
+}
+
+// UnmarshalJSON decodes a message encoded with MarshalJSON
+func (m *SyslogMessage) UnmarshalJSON(data []byte) error {
+	var in syslogMessageJSON
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+
+	switch in.MessageEncoding {
+	case "":
+		m.Message = []byte(in.Message)
+	case messageEncodingBase64:
+		msg, err := base64.StdEncoding.DecodeString(in.Message)
+		if err != nil {
+			return fmt.Errorf("failed to decode message: %v", err)
+		}
+		m.Message = msg
+	default:
+		return fmt.Errorf("unknown message encoding %q", in.MessageEncoding)
+	}
+
+	m.Severity = in.Severity
+	m.Facility = in.Facility
+	m.Hostname = in.Hostname
+	m.Tag = in.Tag
+	m.PID = in.PID
+	m.Partial = in.Partial
+	m.Truncated = in.Truncated
+	m.Timestamp = time.Time{}
+	if in.Timestamp != nil {
+		m.Timestamp = *in.Timestamp
+	}
+	m.Err = nil
+	if in.Error != "" {
+		m.Err = errors.New(in.Error)
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+// +build darwin dragonfly freebsd linux netbsd openbsd solaris
+
+package logging
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	syslog "github.com/RackSec/srslog"
+)
+
+func TestSyslogMessage_JSON(t *testing.T) {
+	t.Parallel()
+	cases := []*SyslogMessage{
+		{
+			Message:  []byte("hello world"),
+			Severity: syslog.LOG_INFO,
+		},
+		{
+			Message:   []byte("oops"),
+			Severity:  syslog.LOG_ERR,
+			Facility:  16,
+			Hostname:  "web-01.example.com",
+			Tag:       "docker/e2a1e3ebd3a3",
+			PID:       22950,
+			Timestamp: time.Date(2016, 10, 6, 0, 17, 9, 669794202, time.UTC),
+			Partial:   true,
+			Truncated: true,
+		},
+		{
+			Message:  []byte{'b', 'a', 'd', 0xff, 0xfe},
+			Severity: syslog.LOG_WARNING,
+		},
+	}
+
+	for _, c := range cases {
+		out, err := json.Marshal(c)
+		if err != nil {
+			t.Fatalf("got an err: %v", err)
+		}
+
+		var decoded SyslogMessage
+		if err := json.Unmarshal(out, &decoded); err != nil {
+			t.Fatalf("got an err: %v", err)
+		}
+		if !reflect.DeepEqual(c, &decoded) {
+			t.Fatalf("expected: %#v, got: %#v", c, &decoded)
+		}
+	}
+}
+
+func TestSyslogMessage_JSON_Format(t *testing.T) {
+	t.Parallel()
+	msg := &SyslogMessage{
+		Message:  []byte("oops"),
+		Severity: syslog.LOG_ERR,
+	}
+	out, err := json.Marshal(msg)
+	if err != nil {
+		t.Fatalf("got an err: %v", err)
+	}
+	expected := `{"Message":"oops","Severity":3,"SeverityName":"error","Facility":0,"FacilityName":"kern"}`
+	if string(out) != expected {
+		t.Fatalf("expected: %s, got: %s", expected, out)
+	}
+
+	// Invalid UTF-8 is base64 encoded
+	msg.Message = []byte{0xff}
+	out, err = json.Marshal(msg)
+	if err != nil {
+		t.Fatalf("got an err: %v", err)
+	}
+	if !strings.Contains(string(out), `"MessageEncoding":"base64"`) {
+		t.Fatalf("expected base64 message encoding, got: %s", out)
+	}
+}
+
+func TestSyslogMessage_FacilityName(t *testing.T) {
+	t.Parallel()
+	for i := 0; i < 8; i++ {
+		msg := &SyslogMessage{Facility: syslog.Priority(16 + i)}
+		expected := fmt.Sprintf("local%d", i)
+		if name := msg.FacilityName(); name != expected {
+			t.Fatalf("facility %d: expected name: %q, got: %q", msg.Facility, expected, name)
+		}
+	}
+
+	msg := &SyslogMessage{Facility: 24}
+	if name := msg.FacilityName(); name != "unknown" {
+		t.Fatalf("expected name: %q, got: %q", "unknown", name)
+	}
+
+	// The facility is decoded from the priority
+	d := NewDockerLogParser(log.New(os.Stdout, "", log.LstdFlags))
+	msg = d.Parse([]byte("<134>2016-02-10T10:16:43-08:00 d-thinkpad docker/e2a1e3ebd3a3[22950]: local0"))
+	if name := msg.FacilityName(); name != "local0" {
+		t.Fatalf("expected name: %q, got: %q", "local0", name)
+	}
+}
@@ -3,9 +3,12 @@
 package logging
 
 import (
+	"bufio"
+	"bytes"
 	"fmt"
 	"log"
-	"strconv"
+	"sync/atomic"
+	"time"
 
 	syslog "github.com/RackSec/srslog"
 )
@@ -20,6 +23,40 @@ var (
 	ErrPriorityNonDigit = fmt.Errorf("Non digit found in priority")
 )
 
+// ErrBadFrameLength is returned when the stream ends before an octet-counted
+// frame is complete
+var ErrBadFrameLength = fmt.Errorf("Malformed octet count in frame")
+
+// FacilityError is returned when a priority decodes to a facility outside of
+// the range defined by RFC 3164
+type FacilityError struct {
+	Facility syslog.Priority
+}
+
+func (e *FacilityError) Error() string {
+	return fmt.Sprintf("Facility %d out of range", e.Facility)
+}
+
+// defaultMaxMessageLength is the default maximum length of a parsed message.
+// It is larger than the longest line the syslog server's scanner accepts.
+const defaultMaxMessageLength = 1024 * 1024
+
+// defaultSeverity and defaultFacility are given to lines that carry no
+// priority. The facility is user-level messages.
+const (
+	defaultSeverity = syslog.LOG_INFO
+	defaultFacility = syslog.Priority(1)
+)
+
+// utf8BOM is the byte order mark RFC 5424 allows at the start of a message
+var utf8BOM = []byte{0xef, 0xbb, 0xbf}
+
+// maxFacility is the largest facility code defined by RFC 3164 (local7)
+const maxFacility = 23
+
+// maxFrameLengthDigits bounds the number of digits accepted in an octet count
+const maxFrameLengthDigits = 8
+
 // Priority header and ending characters
 const (
 	PRI_PART_START = '<'
@@ -30,6 +67,35 @@ const (
 type SyslogMessage struct {
 	Message  []byte
 	Severity syslog.Priority
+
+	// Facility is the RFC 3164 facility code, from 0 (kern) to 23 (local7)
+	Facility syslog.Priority
+
+	// Hostname is the hostname of the sender if it is present in the header
+	Hostname string
+
+	// Tag is the app-name portion of the header, e.g.
+	// "docker/9648c64f5037", if it is present
+	Tag string
+
+	// PID is the process ID enclosed in brackets after the tag, or zero if
+	// it is absent
+	PID int
+
+	// Timestamp is the time the line was logged if the format records it
+	Timestamp time.Time
+
+	// Partial is set when the runtime split a long line and the message
+	// continues in the next message
+	Partial bool
+
+	// Truncated is set when the message was longer than the parser's
+	// maximum message length and was cut short
+	Truncated bool
+
+	// Err is set when the parser is in strict mode and the priority failed
+	// validation. The message is still emitted with a best-guess severity.
+	Err error
 }
 
 // Priority holds all the priority bits in a syslog log line
@@ -42,32 +108,338 @@ type Priority struct {
 // DockerLogParser parses a line of log message that the docker daemon ships
 type DockerLogParser struct {
 	logger *log.Logger
+
+	// minSeverity is the least severe level that is still emitted. Since
+	// syslog severities grow numerically as they become less severe, lines
+	// with a severity greater than minSeverity are dropped.
+	minSeverity syslog.Priority
+
+	// allowedSeverities is the set of severities that are emitted. If nil
+	// every severity up to minSeverity is emitted.
+	allowedSeverities map[syslog.Priority]struct{}
+
+	// strict enables validation of the decoded priority
+	strict bool
+
+	// maxMessageLength is the number of bytes of content copied into a
+	// message. Longer content is truncated. Zero or less is unlimited.
+	maxMessageLength int
+
+	// streamSeverity overrides the severity of lines whose tag identifies
+	// the stream, such as stdout or stderr, they were written to
+	streamSeverity map[string]syslog.Priority
+
+	// severityCounts counts the lines parsed at each severity, including
+	// those below minSeverity. Accessed with atomics.
+	severityCounts [syslog.LOG_DEBUG + 1]uint64
+
+	// parseFailures counts the lines whose priority or header could not be
+	// parsed. Accessed with atomics.
+	parseFailures uint64
 }
 
 // NewDockerLogParser creates a new DockerLogParser
 func NewDockerLogParser(logger *log.Logger) *DockerLogParser {
-	return &DockerLogParser{logger: logger}
+	return &DockerLogParser{
+		logger:           logger,
+		minSeverity:      syslog.LOG_DEBUG,
+		maxMessageLength: defaultMaxMessageLength,
+	}
+}
+
+// SetMinSeverity sets the least severe level that Parse will emit. Lines
+// below the threshold cause Parse to return nil.
+func (d *DockerLogParser) SetMinSeverity(severity syslog.Priority) {
+	d.minSeverity = severity
+}
+
+// SetAllowedSeverities restricts the severities that Parse will emit to the
+// given set. Lines with any other severity, or below the minimum severity,
+// cause Parse to return nil. Calling it with no severities allows all of them.
+func (d *DockerLogParser) SetAllowedSeverities(severities ...syslog.Priority) {
+	if len(severities) == 0 {
+		d.allowedSeverities = nil
+		return
+	}
+	d.allowedSeverities = make(map[syslog.Priority]struct{}, len(severities))
+	for _, s := range severities {
+		d.allowedSeverities[s] = struct{}{}
+	}
+}
+
+// SetStrict toggles strict mode. In strict mode priorities are validated and
+// failures are surfaced on the returned SyslogMessage.
+func (d *DockerLogParser) SetStrict(strict bool) {
+	d.strict = strict
 }
 
-// Parse parses a syslog log line
+// SetMaxMessageLength sets the maximum number of bytes of content copied into
+// a message. Longer content is truncated and flagged on the message. A length
+// of zero or less disables truncation.
+func (d *DockerLogParser) SetMaxMessageLength(length int) {
+	d.maxMessageLength = length
+}
+
+// SetStreamSeverity overrides the severity of lines written to the given
+// stream. The stream is taken from the last path component of the line's tag,
+// e.g. "docker/9648c64f5037/stderr". Lines whose stream can't be determined
+// keep the severity of their priority.
+func (d *DockerLogParser) SetStreamSeverity(stream string, severity syslog.Priority) {
+	if d.streamSeverity == nil {
+		d.streamSeverity = make(map[string]syslog.Priority)
+	}
+	d.streamSeverity[stream] = severity
+}
+
+// ParseFailures returns the number of lines whose priority or header could
+// not be parsed. Each line is counted at most once.
+func (d *DockerLogParser) ParseFailures() uint64 {
+	return atomic.LoadUint64(&d.parseFailures)
+}
+
+// SeverityCounts returns a snapshot of the number of lines parsed at each
+// severity, including lines dropped for being below the minimum severity.
+func (d *DockerLogParser) SeverityCounts() map[syslog.Priority]uint64 {
+	counts := make(map[syslog.Priority]uint64, len(d.severityCounts))
+	for i := range d.severityCounts {
+		counts[syslog.Priority(i)] = atomic.LoadUint64(&d.severityCounts[i])
+	}
+	return counts
+}
+
+// Parse parses a syslog log line. Nil is returned if the line's severity is
+// below the configured minimum severity or not in the allowed set.
 func (d *DockerLogParser) Parse(line []byte) *SyslogMessage {
-	pri, _, _ := d.parsePriority(line)
+	// Empty and whitespace-only lines are common with verbose applications
+	// and carry no priority to parse
+	if len(bytes.TrimSpace(line)) == 0 {
+		atomic.AddUint64(&d.severityCounts[defaultSeverity], 1)
+		if !d.emitSeverity(defaultSeverity) {
+			return nil
+		}
+		return &SyslogMessage{
+			Severity: defaultSeverity,
+			Facility: defaultFacility,
+			Message:  []byte{},
+		}
+	}
+
+	pri, priIdx, err := d.parsePriority(line)
+	failed := err != nil
+
 	msgIdx := d.logContentIndex(line)
+	if msgIdx > len(line) {
+		msgIdx = len(line)
+	}
+
+	// The content is always preceded by a colon and a space
+	if msgIdx < 2 || line[msgIdx-2] != ':' || line[msgIdx-1] != ' ' {
+		failed = true
+	}
+	if failed {
+		atomic.AddUint64(&d.parseFailures, 1)
+	}
+
+	// The header is the portion between the priority and the content. It is
+	// split into fields once for the hostname, tag and stream.
+	var fields [][]byte
+	if priIdx < msgIdx {
+		fields = bytes.Fields(line[priIdx:msgIdx])
+	}
+	tag, pid := d.parseTag(fields)
+
+	severity := pri.Severity
+	if len(d.streamSeverity) != 0 {
+		if override, ok := d.streamSeverity[d.parseStream(tag)]; ok {
+			severity = override
+		}
+	}
+	if severity >= 0 && int(severity) < len(d.severityCounts) {
+		atomic.AddUint64(&d.severityCounts[severity], 1)
+	}
+	if !d.emitSeverity(severity) {
+		return nil
+	}
+
+	content := bytes.TrimPrefix(line[msgIdx:], utf8BOM)
+	truncated := false
+	if d.maxMessageLength > 0 && len(content) > d.maxMessageLength {
+		content = content[:d.maxMessageLength]
+		truncated = true
+	}
 
 	// Create a copy of the line so that subsequent Scans do not override the
 	// message
-	lineCopy := make([]byte, len(line[msgIdx:]))
-	copy(lineCopy, line[msgIdx:])
+	lineCopy := make([]byte, len(content))
+	copy(lineCopy, content)
 
-	return &SyslogMessage{
-		Severity: pri.Severity,
-		Message:  lineCopy,
+	msg := &SyslogMessage{
+		Severity:  severity,
+		Facility:  pri.Facility,
+		Hostname:  d.parseHostname(fields),
+		Tag:       string(tag),
+		PID:       pid,
+		Message:   lineCopy,
+		Truncated: truncated,
 	}
+	if d.strict {
+		msg.Err = d.validatePriority(pri)
+	}
+	return msg
+}
+
+// emitSeverity returns true if lines with the given severity should be emitted
+func (d *DockerLogParser) emitSeverity(severity syslog.Priority) bool {
+	if severity > d.minSeverity {
+		return false
+	}
+	if d.allowedSeverities == nil {
+		return true
+	}
+	_, ok := d.allowedSeverities[severity]
+	return ok
+}
+
+// ScanSyslogFrames is a bufio.SplitFunc that splits a stream into syslog
+// messages. Messages may either be octet-counted ("<len> <pri>...") as
+// described in RFC 6587, in which case exactly len bytes are returned with the
+// count stripped, or newline delimited. Only data starting with a count of up
+// to maxFrameLengthDigits digits followed by a space and the priority start
+// character is treated as octet-counted.
+func ScanSyslogFrames(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	sep, ok, more := octetCountPrefix(data, atEOF)
+	if more {
+		return 0, nil, nil
+	}
+	if !ok {
+		return bufio.ScanLines(data, atEOF)
+	}
+
+	length := 0
+	for _, c := range data[:sep] {
+		length = (length * 10) + int(c-'0')
+	}
+
+	end := sep + 1 + length
+	if len(data) < end {
+		if atEOF {
+			return 0, nil, ErrBadFrameLength
+		}
+		return 0, nil, nil
+	}
+
+	// Some senders terminate octet-counted frames with a newline as well, so
+	// consume it to avoid emitting an empty message
+	advance = end
+	if len(data) > end && data[end] == '\n' {
+		advance++
+	}
+	return advance, data[sep+1 : end], nil
+}
+
+// octetCountPrefix reports whether data starts with an octet count, matching
+// '^[1-9][0-9]{0,7} <', and returns the index of the space following the
+// count. more is true if more data is needed to decide.
+func octetCountPrefix(data []byte, atEOF bool) (sep int, ok bool, more bool) {
+	if len(data) == 0 || data[0] < '1' || data[0] > '9' {
+		return 0, false, false
+	}
+	for i, c := range data {
+		switch {
+		case c >= '0' && c <= '9':
+			if i >= maxFrameLengthDigits {
+				return 0, false, false
+			}
+		case c == ' ':
+			if i+1 == len(data) {
+				return 0, false, !atEOF
+			}
+			return i, data[i+1] == PRI_PART_START, false
+		default:
+			return 0, false, false
+		}
+	}
+	return 0, false, !atEOF
+}
+
+// parseHostname returns the hostname from the fields of the header of a syslog
+// line, which is the portion between the priority and the content. An empty
+// string is returned if the header has no hostname.
+//
+// DefaultFormatter header look: '2016-07-06T15:13:11Z00:00 hostname docker/9648c64f5037[16200]:'
+// UnixFormatter header look: 'Jul  6 15:13:11 docker/9648c64f5037[16200]:'
+func (d *DockerLogParser) parseHostname(fields [][]byte) string {
+	if len(fields) == 0 {
+		return ""
+	}
+
+	// The hostname sits between the timestamp and the tag
+	tsFields := d.timestampFields(fields)
+	if len(fields) != tsFields+2 {
+		return ""
+	}
+	return string(fields[tsFields])
+}
+
+// timestampFields returns the number of fields the timestamp spans in the
+// fields of a header. RFC3339 timestamps are a single field while the unix
+// formatter's timestamp spans the month, day and time fields.
+func (d *DockerLogParser) timestampFields(fields [][]byte) int {
+	if !d.isDigit(fields[0][0]) {
+		return 3
+	}
+	return 1
+}
+
+// parseTag returns the tag and PID from the fields of the header of a syslog
+// line. The tag is the last field of the header, following the timestamp and
+// hostname, with the trailing colon and any bracketed PID removed. A nil tag is
+// returned if the header has no tag and a zero PID if the tag has no PID.
+//
+// Tag look: 'docker/9648c64f5037[16200]:'
+func (d *DockerLogParser) parseTag(fields [][]byte) ([]byte, int) {
+	if len(fields) == 0 || len(fields) <= d.timestampFields(fields) {
+		return nil, 0
+	}
+
+	tag := bytes.TrimSuffix(fields[len(fields)-1], []byte{':'})
+	start := bytes.IndexByte(tag, '[')
+	if start == -1 {
+		return tag, 0
+	}
+
+	pid := 0
+	if end := bytes.IndexByte(tag[start:], ']'); end > 1 {
+		for _, c := range tag[start+1 : start+end] {
+			if !d.isDigit(c) {
+				pid = 0
+				break
+			}
+			pid = (pid * 10) + int(c-'0')
+		}
+	}
+	return tag[:start], pid
+}
+
+// parseStream returns the stream a line was written to, which some tag
+// templates encode as the last path component of the tag. An empty string is
+// returned if the tag has no path components.
+func (d *DockerLogParser) parseStream(tag []byte) string {
+	i := bytes.LastIndexByte(tag, '/')
+	if i == -1 {
+		return ""
+	}
+	return string(tag[i+1:])
 }
 
 // logContentIndex finds out the index of the start index of the content in a
 // syslog line
 func (d *DockerLogParser) logContentIndex(line []byte) int {
+	if idx, ok := d.layoutContentIndex(line); ok {
+		return idx
+	}
+
+	// Fall back to looking for the colons of the timestamp and tag
 	cursor := 0
 	numSpace := 0
 	numColons := 0
@@ -104,6 +476,54 @@ func (d *DockerLogParser) logContentIndex(line []byte) int {
 	return cursor + 1
 }
 
+// layoutContentIndex finds the start index of the content in a syslog line by
+// walking the fields of the header in the order the Docker formatters write
+// them: the timestamp, an optional hostname and the tag, which ends with a
+// colon. Unlike counting colons, this handles tags that contain colons and
+// timestamps that don't. False is returned if the line doesn't follow the
+// layout.
+//
+// DefaultFormatter log line look: '<30>2016-07-06T15:13:11Z00:00 hostname docker/9648c64f5037[16200]: '
+// UnixFormatter log line look: '<30>Jul  6 15:13:11 docker/9648c64f5037[16200]: '
+func (d *DockerLogParser) layoutContentIndex(line []byte) (int, bool) {
+	cursor := 0
+	if len(line) > 0 && line[0] == PRI_PART_START {
+		end := bytes.IndexByte(line, PRI_PART_END)
+		if end == -1 {
+			return 0, false
+		}
+		cursor = end + 1
+	}
+	if cursor >= len(line) {
+		return 0, false
+	}
+
+	tsFields := 1
+	if !d.isDigit(line[cursor]) {
+		tsFields = 3
+	}
+
+	// The tag follows either the timestamp or the hostname
+	for field := 0; field < tsFields+2; field++ {
+		for cursor < len(line) && line[cursor] == ' ' {
+			cursor++
+		}
+		start := cursor
+		for cursor < len(line) && line[cursor] != ' ' {
+			cursor++
+		}
+		if field < tsFields {
+			continue
+		}
+
+		// The tag ends with a colon and is followed by a space
+		if cursor > start && line[cursor-1] == ':' && cursor < len(line) {
+			return cursor + 1, true
+		}
+	}
+	return 0, false
+}
+
 // parsePriority parses the priority in a syslog message
 func (d *DockerLogParser) parsePriority(line []byte) (Priority, int, error) {
 	cursor := 0
@@ -129,11 +549,7 @@ func (d *DockerLogParser) parsePriority(line []byte) (Priority, int, error) {
 			return d.newPriority(priDigit), cursor, nil
 		}
 		if d.isDigit(c) {
-			v, e := strconv.Atoi(string(c))
-			if e != nil {
-				return pri, cursor, e
-			}
-			priDigit = (priDigit * 10) + v
+			priDigit = (priDigit * 10) + int(c-'0')
 		} else {
 			return pri, cursor, ErrPriorityNonDigit
 		}
@@ -147,6 +563,15 @@ func (d *DockerLogParser) isDigit(c byte) bool {
 	return c >= '0' && c <= '9'
 }
 
+// validatePriority checks that the facility of a priority is one of the
+// values defined by RFC 3164
+func (d *DockerLogParser) validatePriority(p Priority) error {
+	if p.Facility > maxFacility {
+		return &FacilityError{Facility: p.Facility}
+	}
+	return nil
+}
+
 // newPriority creates a new default priority
 func (d *DockerLogParser) newPriority(p int) Priority {
 	// The Priority value is calculated by first multiplying the Facility
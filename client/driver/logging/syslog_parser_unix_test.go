@@ -3,9 +3,13 @@
 package logging
 
 import (
+	"bufio"
 	"bytes"
+	"fmt"
 	"log"
 	"os"
+	"reflect"
+	"strings"
 	"testing"
 
 	syslog "github.com/RackSec/srslog"
@@ -48,3 +52,562 @@ func TestLogParser_Priority_UnixFormatter(t *testing.T) {
 		t.Fatalf("expected idx: %v, got: %v", expected, idx)
 	}
 }
+
+func TestLogParser_ContentIndex(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		line    string
+		tag     string
+		message string
+	}{
+		{
+			// The timestamp has no colons so counting colons finds the
+			// ones in the tag
+			line:    "<30>1455127003 d-thinkpad docker/redis:3.2[22950]: ready: accepting connections",
+			tag:     "docker/redis:3.2",
+			message: "ready: accepting connections",
+		},
+		{
+			line:    "<30>2016-02-10T10:16:43-08:00 d-thinkpad docker/redis:3.2[22950]: ready: accepting connections",
+			tag:     "docker/redis:3.2",
+			message: "ready: accepting connections",
+		},
+		{
+			line:    "<30>Feb  6 10:16:43 docker/redis:3.2[22950]: ready: accepting connections",
+			tag:     "docker/redis:3.2",
+			message: "ready: accepting connections",
+		},
+		{
+			line:    "<30>Feb  6, 10:16:43 d-thinkpad docker/e2a1e3ebd3a3[22950]: 1:C 10 Feb 18:16:43.391 # Warning",
+			tag:     "docker/e2a1e3ebd3a3",
+			message: "1:C 10 Feb 18:16:43.391 # Warning",
+		},
+	}
+
+	d := NewDockerLogParser(log.New(os.Stdout, "", log.LstdFlags))
+	for _, c := range cases {
+		msg := d.Parse([]byte(c.line))
+		if msg.Tag != c.tag {
+			t.Fatalf("line %q: expected tag: %q, got: %q", c.line, c.tag, msg.Tag)
+		}
+		if string(msg.Message) != c.message {
+			t.Fatalf("line %q: expected message: %q, got: %q", c.line, c.message, msg.Message)
+		}
+	}
+}
+
+func TestLogParser_MinSeverity(t *testing.T) {
+	t.Parallel()
+	d := NewDockerLogParser(log.New(os.Stdout, "", log.LstdFlags))
+	d.SetMinSeverity(syslog.LOG_INFO)
+
+	info := []byte("<30>2016-02-10T10:16:43-08:00 d-thinkpad docker/e2a1e3ebd3a3[22950]: info line")
+	msg := d.Parse(info)
+	if msg == nil {
+		t.Fatalf("expected info line to be emitted")
+	}
+	if msg.Severity != syslog.LOG_INFO {
+		t.Fatalf("expected severity: %v, got: %v", syslog.LOG_INFO, msg.Severity)
+	}
+
+	debug := []byte("<31>2016-02-10T10:16:43-08:00 d-thinkpad docker/e2a1e3ebd3a3[22950]: debug line")
+	if msg := d.Parse(debug); msg != nil {
+		t.Fatalf("expected debug line to be dropped, got: %#v", msg)
+	}
+
+	// The default emits every severity
+	d = NewDockerLogParser(log.New(os.Stdout, "", log.LstdFlags))
+	if msg := d.Parse(debug); msg == nil {
+		t.Fatalf("expected debug line to be emitted by default")
+	}
+}
+
+func TestLogParser_AllowedSeverities(t *testing.T) {
+	t.Parallel()
+	d := NewDockerLogParser(log.New(os.Stdout, "", log.LstdFlags))
+	d.SetAllowedSeverities(syslog.LOG_ERR, syslog.LOG_WARNING)
+
+	errLine := []byte("<27>2016-02-10T10:16:43-08:00 d-thinkpad docker/e2a1e3ebd3a3[22950]: error")
+	warnLine := []byte("<28>2016-02-10T10:16:43-08:00 d-thinkpad docker/e2a1e3ebd3a3[22950]: warning")
+	infoLine := []byte("<30>2016-02-10T10:16:43-08:00 d-thinkpad docker/e2a1e3ebd3a3[22950]: info")
+	critLine := []byte("<26>2016-02-10T10:16:43-08:00 d-thinkpad docker/e2a1e3ebd3a3[22950]: crit")
+
+	for _, line := range [][]byte{errLine, warnLine} {
+		if msg := d.Parse(line); msg == nil {
+			t.Fatalf("line %q: expected a message", line)
+		}
+	}
+	for _, line := range [][]byte{infoLine, critLine, []byte("")} {
+		if msg := d.Parse(line); msg != nil {
+			t.Fatalf("line %q: expected line to be dropped, got: %#v", line, msg)
+		}
+	}
+
+	// The allowed set composes with the minimum severity
+	d.SetMinSeverity(syslog.LOG_ERR)
+	if msg := d.Parse(warnLine); msg != nil {
+		t.Fatalf("expected warning to be dropped, got: %#v", msg)
+	}
+	if msg := d.Parse(errLine); msg == nil {
+		t.Fatalf("expected a message")
+	}
+
+	// Clearing the set allows every severity again
+	d.SetAllowedSeverities()
+	if msg := d.Parse(critLine); msg == nil {
+		t.Fatalf("expected a message")
+	}
+}
+
+func TestLogParser_ScanSyslogFrames(t *testing.T) {
+	t.Parallel()
+	multiline := "<30>2016-02-10T10:16:43-08:00 d-thinkpad docker/e2a1e3ebd3a3[22950]: first\nsecond"
+	plain := "<27>2016-02-10T10:16:44-08:00 d-thinkpad docker/e2a1e3ebd3a3[22950]: plain"
+	counted := "<30>2016-02-10T10:16:45-08:00 d-thinkpad docker/e2a1e3ebd3a3[22950]: counted"
+	stream := fmt.Sprintf("%d %s%s\n%d %s\n", len(multiline), multiline, plain, len(counted), counted)
+
+	scanner := bufio.NewScanner(strings.NewReader(stream))
+	scanner.Split(ScanSyslogFrames)
+	var frames []string
+	for scanner.Scan() {
+		frames = append(frames, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatalf("got an err: %v", err)
+	}
+
+	expected := []string{multiline, plain, counted}
+	if !reflect.DeepEqual(frames, expected) {
+		t.Fatalf("expected frames: %q, got: %q", expected, frames)
+	}
+
+	d := NewDockerLogParser(log.New(os.Stdout, "", log.LstdFlags))
+	msg := d.Parse([]byte(frames[0]))
+	if string(msg.Message) != "first\nsecond" {
+		t.Fatalf("expected message to retain newline, got: %q", msg.Message)
+	}
+}
+
+func TestLogParser_ScanSyslogFrames_BadLength(t *testing.T) {
+	t.Parallel()
+	scanner := bufio.NewScanner(strings.NewReader("50 <30>short\n"))
+	scanner.Split(ScanSyslogFrames)
+	for scanner.Scan() {
+	}
+	if err := scanner.Err(); err != ErrBadFrameLength {
+		t.Fatalf("expected err: %v, got: %v", ErrBadFrameLength, err)
+	}
+}
+
+// TestLogParser_ScanSyslogFrames_LeadingDigits asserts newline delimited
+// lines that start with digits aren't mistaken for octet-counted frames.
+func TestLogParser_ScanSyslogFrames_LeadingDigits(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		input  string
+		frames []string
+	}{
+		{
+			input:  "5 apples\n<30>x: y\n",
+			frames: []string{"5 apples", "<30>x: y"},
+		},
+		{
+			input:  "2016-07-06T15:13:11Z host foo: bar\n<30>next\n",
+			frames: []string{"2016-07-06T15:13:11Z host foo: bar", "<30>next"},
+		},
+		{
+			input:  "12x <30>message\n",
+			frames: []string{"12x <30>message"},
+		},
+		{
+			input:  "123456789 <30>message\n",
+			frames: []string{"123456789 <30>message"},
+		},
+		{
+			input:  "42\n",
+			frames: []string{"42"},
+		},
+		{
+			input:  "8 <30>a: b",
+			frames: []string{"<30>a: b"},
+		},
+	}
+
+	for _, c := range cases {
+		scanner := bufio.NewScanner(strings.NewReader(c.input))
+		scanner.Split(ScanSyslogFrames)
+		var frames []string
+		for scanner.Scan() {
+			frames = append(frames, scanner.Text())
+		}
+		if err := scanner.Err(); err != nil {
+			t.Fatalf("input %q: got an err: %v", c.input, err)
+		}
+		if !reflect.DeepEqual(frames, c.frames) {
+			t.Fatalf("input %q: expected frames: %q, got: %q", c.input, c.frames, frames)
+		}
+	}
+}
+
+func TestLogParser_Strict_Facility(t *testing.T) {
+	t.Parallel()
+	line := []byte("<199>2016-02-10T10:16:43-08:00 d-thinkpad docker/e2a1e3ebd3a3[22950]: double encoded")
+	d := NewDockerLogParser(log.New(os.Stdout, "", log.LstdFlags))
+
+	// Lenient mode emits the message without an error
+	msg := d.Parse(line)
+	if msg.Err != nil {
+		t.Fatalf("unexpected err in lenient mode: %v", msg.Err)
+	}
+
+	d.SetStrict(true)
+	msg = d.Parse(line)
+	ferr, ok := msg.Err.(*FacilityError)
+	if !ok {
+		t.Fatalf("expected a FacilityError, got: %#v", msg.Err)
+	}
+	if ferr.Facility != 24 {
+		t.Fatalf("expected facility: 24, got: %v", ferr.Facility)
+	}
+	if msg.Severity != syslog.LOG_DEBUG {
+		t.Fatalf("expected severity: %v, got: %v", syslog.LOG_DEBUG, msg.Severity)
+	}
+	if string(msg.Message) != "double encoded" {
+		t.Fatalf("unexpected message: %q", msg.Message)
+	}
+
+	// local7 is the largest valid facility
+	msg = d.Parse([]byte("<191>2016-02-10T10:16:43-08:00 d-thinkpad docker/e2a1e3ebd3a3[22950]: local7"))
+	if msg.Err != nil {
+		t.Fatalf("unexpected err: %v", msg.Err)
+	}
+}
+
+func TestLogParser_Hostname(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		line     string
+		hostname string
+		message  string
+	}{
+		{
+			line:     "<30>2016-02-10T10:16:43-08:00 d-thinkpad docker/e2a1e3ebd3a3[22950]: hello world",
+			hostname: "d-thinkpad",
+			message:  "hello world",
+		},
+		{
+			line:     "<30>2016-02-10T10:16:43-08:00 web-01.example.com docker/e2a1e3ebd3a3[22950]: hello world",
+			hostname: "web-01.example.com",
+			message:  "hello world",
+		},
+		{
+			line:     "<30>2016-02-10T10:16:43-08:00 docker/e2a1e3ebd3a3[22950]: hello world",
+			hostname: "",
+			message:  "hello world",
+		},
+		{
+			line:     "<30>Feb  6, 10:16:43 docker/e2a1e3ebd3a3[22950]: hello world",
+			hostname: "",
+			message:  "hello world",
+		},
+		{
+			line:     "<30>Feb  6 10:16:43 web-01.example.com docker/e2a1e3ebd3a3[22950]: hello world",
+			hostname: "web-01.example.com",
+			message:  "hello world",
+		},
+	}
+
+	d := NewDockerLogParser(log.New(os.Stdout, "", log.LstdFlags))
+	for _, c := range cases {
+		msg := d.Parse([]byte(c.line))
+		if msg.Hostname != c.hostname {
+			t.Fatalf("line %q: expected hostname: %q, got: %q", c.line, c.hostname, msg.Hostname)
+		}
+		if string(msg.Message) != c.message {
+			t.Fatalf("line %q: expected message: %q, got: %q", c.line, c.message, msg.Message)
+		}
+	}
+}
+
+func TestLogParser_Tag(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		line string
+		tag  string
+		pid  int
+	}{
+		{
+			line: "<30>2016-02-10T10:16:43-08:00 d-thinkpad docker/e2a1e3ebd3a3[22950]: hello world",
+			tag:  "docker/e2a1e3ebd3a3",
+			pid:  22950,
+		},
+		{
+			line: "<30>Feb  6 10:16:43 docker/e2a1e3ebd3a3[22950]: hello world",
+			tag:  "docker/e2a1e3ebd3a3",
+			pid:  22950,
+		},
+		{
+			line: "<30>2016-02-10T10:16:43-08:00 d-thinkpad docker/redis/cache/e2a1e3ebd3a3[1]: hello world",
+			tag:  "docker/redis/cache/e2a1e3ebd3a3",
+			pid:  1,
+		},
+		{
+			line: "<30>Feb  6 10:16:43 web-01.example.com docker/redis/cache: hello world",
+			tag:  "docker/redis/cache",
+			pid:  0,
+		},
+		{
+			line: "<30>2016-02-10T10:16:43-08:00 d-thinkpad docker/e2a1e3ebd3a3[abc]: hello world",
+			tag:  "docker/e2a1e3ebd3a3",
+			pid:  0,
+		},
+	}
+
+	d := NewDockerLogParser(log.New(os.Stdout, "", log.LstdFlags))
+	for _, c := range cases {
+		msg := d.Parse([]byte(c.line))
+		if msg.Tag != c.tag {
+			t.Fatalf("line %q: expected tag: %q, got: %q", c.line, c.tag, msg.Tag)
+		}
+		if msg.PID != c.pid {
+			t.Fatalf("line %q: expected pid: %d, got: %d", c.line, c.pid, msg.PID)
+		}
+		if string(msg.Message) != "hello world" {
+			t.Fatalf("line %q: unexpected message: %q", c.line, msg.Message)
+		}
+	}
+}
+
+func TestLogParser_BOM(t *testing.T) {
+	t.Parallel()
+	d := NewDockerLogParser(log.New(os.Stdout, "", log.LstdFlags))
+
+	msg := d.Parse([]byte("<30>2016-02-10T10:16:43-08:00 d-thinkpad docker/e2a1e3ebd3a3[22950]: \xef\xbb\xbfhello \xef\xbb\xbf world"))
+	if expected := "hello \xef\xbb\xbf world"; string(msg.Message) != expected {
+		t.Fatalf("expected message: %q, got: %q", expected, msg.Message)
+	}
+
+	msg = d.Parse([]byte("<30>2016-02-10T10:16:43-08:00 d-thinkpad docker/e2a1e3ebd3a3[22950]: hello world"))
+	if expected := "hello world"; string(msg.Message) != expected {
+		t.Fatalf("expected message: %q, got: %q", expected, msg.Message)
+	}
+}
+
+func TestLogParser_SeverityCounts(t *testing.T) {
+	t.Parallel()
+	d := NewDockerLogParser(log.New(os.Stdout, "", log.LstdFlags))
+	d.SetMinSeverity(syslog.LOG_WARNING)
+
+	lines := []string{
+		"<27>2016-02-10T10:16:43-08:00 d-thinkpad docker/e2a1e3ebd3a3[22950]: error",
+		"<27>2016-02-10T10:16:43-08:00 d-thinkpad docker/e2a1e3ebd3a3[22950]: error",
+		"<28>2016-02-10T10:16:43-08:00 d-thinkpad docker/e2a1e3ebd3a3[22950]: warning",
+		"<30>2016-02-10T10:16:43-08:00 d-thinkpad docker/e2a1e3ebd3a3[22950]: info",
+		"",
+	}
+	for _, line := range lines {
+		d.Parse([]byte(line))
+	}
+
+	counts := d.SeverityCounts()
+	expected := map[syslog.Priority]uint64{
+		syslog.LOG_EMERG:   0,
+		syslog.LOG_ALERT:   0,
+		syslog.LOG_CRIT:    0,
+		syslog.LOG_ERR:     2,
+		syslog.LOG_WARNING: 1,
+		syslog.LOG_NOTICE:  0,
+		syslog.LOG_INFO:    2,
+		syslog.LOG_DEBUG:   0,
+	}
+	if !reflect.DeepEqual(counts, expected) {
+		t.Fatalf("expected counts: %v, got: %v", expected, counts)
+	}
+
+	// The snapshot is not affected by later lines
+	d.Parse([]byte(lines[0]))
+	if counts[syslog.LOG_ERR] != 2 {
+		t.Fatalf("expected snapshot to be unchanged, got: %v", counts)
+	}
+}
+
+func TestLogParser_EmptyLines(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	d := NewDockerLogParser(log.New(&buf, "", log.LstdFlags))
+	d.SetStrict(true)
+
+	for _, line := range []string{"", "\n", "   "} {
+		msg := d.Parse([]byte(line))
+		if msg == nil {
+			t.Fatalf("line %q: expected a message", line)
+		}
+		if len(msg.Message) != 0 {
+			t.Fatalf("line %q: expected an empty message, got: %q", line, msg.Message)
+		}
+		if msg.Severity != syslog.LOG_INFO {
+			t.Fatalf("line %q: expected severity: %v, got: %v", line, syslog.LOG_INFO, msg.Severity)
+		}
+		if msg.Err != nil {
+			t.Fatalf("line %q: unexpected err: %v", line, msg.Err)
+		}
+	}
+
+	if buf.Len() != 0 {
+		t.Fatalf("expected no log output, got: %q", buf.String())
+	}
+}
+
+func TestLogParser_Priority_Errors(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		line string
+		err  error
+	}{
+		{"", ErrPriorityEmpty},
+		{"30>", ErrPriorityNoStart},
+		{"<>", ErrPriorityTooShort},
+		{"<1234>", ErrPriorityTooLong},
+		{"<3a>", ErrPriorityNonDigit},
+		{"<30", ErrPriorityNoEnd},
+	}
+
+	d := NewDockerLogParser(log.New(os.Stdout, "", log.LstdFlags))
+	for _, c := range cases {
+		if _, _, err := d.parsePriority([]byte(c.line)); err != c.err {
+			t.Fatalf("line %q: expected err: %v, got: %v", c.line, c.err, err)
+		}
+	}
+
+	p, cursor, err := d.parsePriority([]byte("<191>"))
+	if err != nil {
+		t.Fatalf("got an err: %v", err)
+	}
+	if p.Pri != 191 || cursor != 5 {
+		t.Fatalf("expected pri 191 at cursor 5, got: %d at %d", p.Pri, cursor)
+	}
+}
+
+func BenchmarkLogParser_Priority(b *testing.B) {
+	line := []byte("<191>2016-02-10T10:16:43-08:00 d-thinkpad docker/e2a1e3ebd3a3[22950]: benchmark")
+	d := NewDockerLogParser(log.New(os.Stdout, "", log.LstdFlags))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		d.parsePriority(line)
+	}
+}
+
+func BenchmarkLogParser_Parse(b *testing.B) {
+	line := []byte("<30>2016-02-10T10:16:43-08:00 d-thinkpad docker/e2a1e3ebd3a3/stdout[22950]: 1:C 10 Feb 18:16:43.391 # Warning: no config file specified")
+	d := NewDockerLogParser(log.New(os.Stdout, "", log.LstdFlags))
+	d.SetStreamSeverity("stderr", syslog.LOG_ERR)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		d.Parse(line)
+	}
+}
+
+func TestLogParser_ParseFailures(t *testing.T) {
+	t.Parallel()
+	d := NewDockerLogParser(log.New(os.Stdout, "", log.LstdFlags))
+
+	// A well formed line doesn't count as a failure
+	d.Parse([]byte("<30>2016-02-10T10:16:43-08:00 d-thinkpad docker/e2a1e3ebd3a3[22950]: ok"))
+	if n := d.ParseFailures(); n != 0 {
+		t.Fatalf("unexpected parse failures: %d", n)
+	}
+
+	// A line missing both the priority and the content separator is counted
+	// once
+	d.Parse([]byte("malformed"))
+	if n := d.ParseFailures(); n != 1 {
+		t.Fatalf("expected 1 failure, got: %d", n)
+	}
+
+	d.Parse([]byte("<30>no separator"))
+	if n := d.ParseFailures(); n != 2 {
+		t.Fatalf("expected 2 failures, got: %d", n)
+	}
+}
+
+func TestLogParser_MaxMessageLength(t *testing.T) {
+	t.Parallel()
+	d := NewDockerLogParser(log.New(os.Stdout, "", log.LstdFlags))
+	d.SetMaxMessageLength(5)
+
+	msg := d.Parse([]byte("<30>2016-02-10T10:16:43-08:00 d-thinkpad docker/e2a1e3ebd3a3[22950]: hello world"))
+	if string(msg.Message) != "hello" {
+		t.Fatalf("expected truncated message, got: %q", msg.Message)
+	}
+	if !msg.Truncated {
+		t.Fatalf("expected message to be flagged as truncated")
+	}
+
+	msg = d.Parse([]byte("<30>2016-02-10T10:16:43-08:00 d-thinkpad docker/e2a1e3ebd3a3[22950]: hi"))
+	if string(msg.Message) != "hi" {
+		t.Fatalf("unexpected message: %q", msg.Message)
+	}
+	if msg.Truncated {
+		t.Fatalf("unexpected truncation")
+	}
+
+	// Zero and negative lengths disable truncation
+	for _, length := range []int{0, -1} {
+		d.SetMaxMessageLength(length)
+		msg = d.Parse([]byte("<30>2016-02-10T10:16:43-08:00 d-thinkpad docker/e2a1e3ebd3a3[22950]: hello world"))
+		if string(msg.Message) != "hello world" {
+			t.Fatalf("length %d: unexpected message: %q", length, msg.Message)
+		}
+		if msg.Truncated {
+			t.Fatalf("length %d: unexpected truncation", length)
+		}
+	}
+}
+
+func TestLogParser_StreamSeverity(t *testing.T) {
+	t.Parallel()
+	d := NewDockerLogParser(log.New(os.Stdout, "", log.LstdFlags))
+	d.SetStreamSeverity("stdout", syslog.LOG_INFO)
+	d.SetStreamSeverity("stderr", syslog.LOG_ERR)
+
+	cases := []struct {
+		line     string
+		severity syslog.Priority
+	}{
+		{
+			line:     "<29>2016-02-10T10:16:43-08:00 d-thinkpad docker/e2a1e3ebd3a3/stdout[22950]: hello world",
+			severity: syslog.LOG_INFO,
+		},
+		{
+			line:     "<30>2016-02-10T10:16:43-08:00 d-thinkpad docker/e2a1e3ebd3a3/stderr[22950]: hello world",
+			severity: syslog.LOG_ERR,
+		},
+		{
+			line:     "<30>Feb  6 10:16:43 docker/e2a1e3ebd3a3/stderr: hello world",
+			severity: syslog.LOG_ERR,
+		},
+		{
+			// The stream can't be determined so the priority is used
+			line:     "<28>2016-02-10T10:16:43-08:00 d-thinkpad docker/e2a1e3ebd3a3[22950]: hello world",
+			severity: syslog.LOG_WARNING,
+		},
+	}
+
+	for _, c := range cases {
+		msg := d.Parse([]byte(c.line))
+		if msg.Severity != c.severity {
+			t.Fatalf("line %q: expected severity: %v, got: %v", c.line, c.severity, msg.Severity)
+		}
+		if string(msg.Message) != "hello world" {
+			t.Fatalf("line %q: unexpected message: %q", c.line, msg.Message)
+		}
+	}
+
+	// The minimum severity applies to the overridden severity
+	d.SetMinSeverity(syslog.LOG_WARNING)
+	if msg := d.Parse([]byte("<27>2016-02-10T10:16:43-08:00 d-thinkpad docker/e2a1e3ebd3a3/stdout[22950]: hello world")); msg != nil {
+		t.Fatalf("expected stdout line to be dropped, got: %#v", msg)
+	}
+}
@@ -2,6 +2,9 @@ package logging
 
 import (
 	"bufio"
+	"bytes"
+	"compress/gzip"
+	"io"
 	"log"
 	"net"
 	"sync"
@@ -11,7 +14,7 @@ import (
 type SyslogServer struct {
 	listener net.Listener
 	messages chan *SyslogMessage
-	parser   *DockerLogParser
+	parser   LogParser
 
 	doneCh   chan interface{}
 	done     bool
@@ -32,6 +35,15 @@ func NewSyslogServer(l net.Listener, messages chan *SyslogMessage, logger *log.L
 	}
 }
 
+// ParseFailures returns the number of received lines that could not be
+// parsed
+func (s *SyslogServer) ParseFailures() uint64 {
+	if p, ok := s.parser.(*DockerLogParser); ok {
+		return p.ParseFailures()
+	}
+	return 0
+}
+
 // Start starts accepting syslog connections
 func (s *SyslogServer) Start() {
 	for {
@@ -56,22 +68,56 @@ func (s *SyslogServer) Start() {
 	}
 }
 
+// gzipMagic is the header that starts a gzip stream
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// newFrameReader returns a reader over the syslog frames of a connection.
+// Connections starting with a gzip header are inflated so that compressed and
+// plain streams are split into frames the same way.
+func newFrameReader(r *bufio.Reader) (io.Reader, error) {
+	header, err := r.Peek(len(gzipMagic))
+	if err != nil || !bytes.Equal(header, gzipMagic) {
+		return r, nil
+	}
+	return gzip.NewReader(r)
+}
+
 // read reads the bytes from a connection
 func (s *SyslogServer) read(connection net.Conn) {
 	defer connection.Close()
-	scanner := bufio.NewScanner(bufio.NewReader(connection))
+	r, err := newFrameReader(bufio.NewReader(connection))
+	if err != nil {
+		s.logger.Printf("[ERR] logcollector.server: error reading gzip stream: %v", err)
+		return
+	}
 
+	parser := NewStreamParser(s.parser)
+	send := func(msg *SyslogMessage) {
+		s.messages <- msg
+	}
+
+	buf := make([]byte, 4096)
 	for {
 		select {
 		case <-s.doneCh:
 			return
 		default:
 		}
-		if scanner.Scan() {
-			b := scanner.Bytes()
-			msg := s.parser.Parse(b)
-			s.messages <- msg
-		} else {
+		n, err := r.Read(buf)
+		if n > 0 {
+			if err := parser.Feed(buf[:n], send); err != nil {
+				s.logger.Printf("[ERR] logcollector.server: error reading syslog frame: %v", err)
+				return
+			}
+		}
+		if err == io.EOF {
+			if err := parser.Flush(send); err != nil {
+				s.logger.Printf("[ERR] logcollector.server: error reading syslog frame: %v", err)
+			}
+			return
+		}
+		if err != nil {
+			s.logger.Printf("[ERR] logcollector.server: error reading connection: %v", err)
 			return
 		}
 	}
@@ -1,12 +1,17 @@
 package logging
 
 import (
+	"bytes"
+	"compress/gzip"
 	"io/ioutil"
+	"log"
 	"net"
 	"os"
 	"path"
 	"testing"
 	"time"
+
+	syslog "github.com/RackSec/srslog"
 )
 
 func TestSyslogServer_Start_Shutdown(t *testing.T) {
@@ -62,3 +67,69 @@ func TestSyslogServer_Start_Shutdown(t *testing.T) {
 		t.Fatalf("expected SyslogServer done, but running")
 	}
 }
+
+func TestSyslogServer_Read(t *testing.T) {
+	t.Parallel()
+	lines := "<30>2016-02-10T10:16:43-08:00 d-thinkpad docker/e2a1e3ebd3a3[22950]: first\n" +
+		"<27>2016-02-10T10:16:44-08:00 d-thinkpad docker/e2a1e3ebd3a3[22950]: second\n"
+
+	var gz bytes.Buffer
+	w := gzip.NewWriter(&gz)
+	w.Write([]byte(lines))
+	if err := w.Close(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	cases := []struct {
+		Name  string
+		Input [][]byte
+	}{
+		{
+			Name:  "plain",
+			Input: [][]byte{[]byte(lines)},
+		},
+		{
+			Name:  "gzip",
+			Input: [][]byte{gz.Bytes()},
+		},
+		{
+			Name: "split",
+			Input: [][]byte{
+				[]byte(lines[:30]),
+				[]byte(lines[30 : len(lines)-1]),
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			logger := log.New(os.Stdout, "", log.LstdFlags)
+			s := NewSyslogServer(nil, make(chan *SyslogMessage, 10), logger)
+
+			client, server := net.Pipe()
+			go func() {
+				for _, b := range tc.Input {
+					client.Write(b)
+				}
+				client.Close()
+			}()
+			s.read(server)
+			close(s.messages)
+
+			var msgs []*SyslogMessage
+			for msg := range s.messages {
+				msgs = append(msgs, msg)
+			}
+			if len(msgs) != 2 {
+				t.Fatalf("expected 2 messages, got: %d", len(msgs))
+			}
+			if string(msgs[0].Message) != "first" || msgs[0].Severity != syslog.LOG_INFO {
+				t.Fatalf("unexpected first message: %#v", msgs[0])
+			}
+			if string(msgs[1].Message) != "second" || msgs[1].Severity != syslog.LOG_ERR {
+				t.Fatalf("unexpected second message: %#v", msgs[1])
+			}
+		})
+	}
+}
@@ -840,6 +840,8 @@ func (a *Agent) setupConsul(consulConfig *config.ConsulConfig) error {
 
 	// Create Consul Service client for service advertisement and checks.
 	a.consulService = consul.NewServiceClient(client.Agent(), a.consulSupportsTLSSkipVerify, a.logger)
+	a.consulService.SetDeregisterGracePeriod(consulConfig.DeregisterGracePeriod)
+	a.consulService.SetScriptOutputLimit(consulConfig.ScriptOutputLimit)
 
 	// Run the Consul service client's sync'ing main loop
 	go a.consulService.Run()
@@ -126,6 +126,8 @@ consul {
     client_auto_join = true
     auto_advertise = true
     checks_use_advertise = true
+    deregister_grace_period = "30s"
+    script_output_limit = 1024
 }
 vault {
     address = "127.0.0.1:9500"
@@ -692,7 +692,9 @@ func parseConsulConfig(result **config.ConsulConfig, list *ast.ObjectList) error
 		"checks_use_advertise",
 		"client_auto_join",
 		"client_service_name",
+		"deregister_grace_period",
 		"key_file",
+		"script_output_limit",
 		"server_auto_join",
 		"server_service_name",
 		"ssl",
@@ -132,20 +132,22 @@ func TestConfig_Parse(t *testing.T) {
 				DisableUpdateCheck:        true,
 				DisableAnonymousSignature: true,
 				Consul: &config.ConsulConfig{
-					ServerServiceName:  "nomad",
-					ClientServiceName:  "nomad-client",
-					Addr:               "127.0.0.1:9500",
-					Token:              "token1",
-					Auth:               "username:pass",
-					EnableSSL:          &trueValue,
-					VerifySSL:          &trueValue,
-					CAFile:             "/path/to/ca/file",
-					CertFile:           "/path/to/cert/file",
-					KeyFile:            "/path/to/key/file",
-					ServerAutoJoin:     &trueValue,
-					ClientAutoJoin:     &trueValue,
-					AutoAdvertise:      &trueValue,
-					ChecksUseAdvertise: &trueValue,
+					ServerServiceName:     "nomad",
+					ClientServiceName:     "nomad-client",
+					Addr:                  "127.0.0.1:9500",
+					Token:                 "token1",
+					Auth:                  "username:pass",
+					EnableSSL:             &trueValue,
+					VerifySSL:             &trueValue,
+					CAFile:                "/path/to/ca/file",
+					CertFile:              "/path/to/cert/file",
+					KeyFile:               "/path/to/key/file",
+					ServerAutoJoin:        &trueValue,
+					ClientAutoJoin:        &trueValue,
+					AutoAdvertise:         &trueValue,
+					ChecksUseAdvertise:    &trueValue,
+					DeregisterGracePeriod: 30 * time.Second,
+					ScriptOutputLimit:     1024,
 				},
 				Vault: &config.VaultConfig{
 					Addr:                 "127.0.0.1:9500",
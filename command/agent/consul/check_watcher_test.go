@@ -124,7 +124,7 @@ func testCheck() *structs.ServiceCheck {
 	return &structs.ServiceCheck{
 		Name:     "testcheck",
 		Interval: 100 * time.Millisecond,
-		Timeout:  100 * time.Millisecond,
+		Timeout:  50 * time.Millisecond,
 		CheckRestart: &structs.CheckRestart{
 			Limit:          3,
 			Grace:          100 * time.Millisecond,
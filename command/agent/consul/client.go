@@ -36,6 +36,12 @@ const (
 	// defaultMaxRetryInterval is the default max retry interval.
 	defaultMaxRetryInterval = 30 * time.Second
 
+	// defaultACLRetryInterval is how long to wait before retrying a sync
+	// that failed because the ACL token lacks permissions. Such failures
+	// persist until the token's policy is changed, so they're retried far
+	// less often than other failures.
+	defaultACLRetryInterval = 5 * time.Minute
+
 	// ttlCheckBuffer is the time interval that Nomad can take to report Consul
 	// the check result
 	ttlCheckBuffer = 31 * time.Second
@@ -189,6 +195,7 @@ type ServiceClient struct {
 	logger           *log.Logger
 	retryInterval    time.Duration
 	maxRetryInterval time.Duration
+	aclRetryInterval time.Duration
 
 	// skipVerifySupport is true if the local Consul agent suppots TLSSkipVerify
 	skipVerifySupport bool
@@ -227,6 +234,31 @@ type ServiceClient struct {
 
 	// checkWatcher restarts checks that are unhealthy.
 	checkWatcher *checkWatcher
+
+	// deregisterGracePeriod is how long after Run starts that Nomad
+	// services and checks unknown to this client are left registered in
+	// Consul, giving restored allocations time to register theirs again.
+	deregisterGracePeriod time.Duration
+
+	// deregisterGraceExpiry is when the grace period ends. It is only
+	// accessed by the Run loop.
+	deregisterGraceExpiry time.Time
+
+	// scriptOutputLimit is the number of bytes of script check output
+	// reported to Consul. If zero the default is used.
+	scriptOutputLimit int
+
+	// explicitDeregServices and explicitDeregChecks are the IDs of services
+	// and checks removed by operations during the grace period. They are
+	// deregistered even though the grace period hasn't ended.
+	explicitDeregServices map[string]struct{}
+	explicitDeregChecks   map[string]struct{}
+
+	// lastSyncSuccess is when the Run loop last synced successfully and
+	// lastSyncErr is the error of its most recent sync, if it failed.
+	lastSyncSuccess time.Time
+	lastSyncErr     error
+	syncStatusLock  sync.RWMutex
 }
 
 // NewServiceClient creates a new Consul ServiceClient from an existing Consul API
@@ -238,6 +270,7 @@ func NewServiceClient(consulClient AgentAPI, skipVerifySupport bool, logger *log
 		logger:             logger,
 		retryInterval:      defaultRetryInterval,
 		maxRetryInterval:   defaultMaxRetryInterval,
+		aclRetryInterval:   defaultACLRetryInterval,
 		exitCh:             make(chan struct{}),
 		shutdownCh:         make(chan struct{}),
 		shutdownWait:       defaultShutdownWait,
@@ -250,9 +283,28 @@ func NewServiceClient(consulClient AgentAPI, skipVerifySupport bool, logger *log
 		agentServices:      make(map[string]struct{}),
 		agentChecks:        make(map[string]struct{}),
 		checkWatcher:       newCheckWatcher(logger, consulClient),
+
+		explicitDeregServices: make(map[string]struct{}),
+		explicitDeregChecks:   make(map[string]struct{}),
 	}
 }
 
+// SetDeregisterGracePeriod sets how long after Run starts that Nomad services
+// and checks unknown to this client are left registered in Consul. Services
+// and checks explicitly removed are still deregistered during the grace
+// period. It must be called before Run.
+func (c *ServiceClient) SetDeregisterGracePeriod(d time.Duration) {
+	c.deregisterGracePeriod = d
+}
+
+// SetScriptOutputLimit sets the number of bytes of script check output
+// reported to Consul. Longer output is truncated to its end. Drivers capture at
+// most 4KB of output so larger limits have no effect. A limit of zero or less
+// uses the default. It must be called before Run.
+func (c *ServiceClient) SetScriptOutputLimit(limit int) {
+	c.scriptOutputLimit = limit
+}
+
 // seen is used by markSeen and hasSeen
 const seen = 1
 
@@ -273,6 +325,8 @@ func (c *ServiceClient) hasSeen() bool {
 func (c *ServiceClient) Run() {
 	defer close(c.exitCh)
 
+	c.deregisterGraceExpiry = time.Now().Add(c.deregisterGracePeriod)
+
 	// start checkWatcher
 	ctx, cancelWatcher := context.WithCancel(context.Background())
 	defer cancelWatcher()
@@ -280,20 +334,38 @@ func (c *ServiceClient) Run() {
 
 	retryTimer := time.NewTimer(0)
 	<-retryTimer.C // disabled by default
+
+	// Sync when the deregister grace period ends so unknown services and
+	// checks are removed even if no operations are committed
+	var graceCh <-chan time.Time
+	if c.deregisterGracePeriod > 0 {
+		graceTimer := time.NewTimer(c.deregisterGracePeriod)
+		defer graceTimer.Stop()
+		graceCh = graceTimer.C
+	}
+
 	failures := 0
 	for {
 		select {
 		case <-retryTimer.C:
+		case <-graceCh:
+			graceCh = nil
 		case <-c.shutdownCh:
 			cancelWatcher()
 		case ops := <-c.opCh:
 			c.merge(ops)
 		}
 
-		if err := c.sync(); err != nil {
+		err := c.sync()
+		c.setSyncStatus(err)
+		if err != nil {
 			if failures == 0 {
 				// Log on the first failure
-				c.logger.Printf("[WARN] consul.sync: failed to update services in Consul: %v", err)
+				if isPermissionDenied(err) {
+					c.logger.Printf("[ERR] consul.sync: Consul ACL token lacks permission to update services; retrying every %s: %v", c.aclRetryInterval, err)
+				} else {
+					c.logger.Printf("[WARN] consul.sync: failed to update services in Consul: %v", err)
+				}
 			} else if failures%10 == 0 {
 				// Log every 10th consecutive failure
 				c.logger.Printf("[ERR] consul.sync: still unable to update services in Consul after %d failures; latest error: %v", failures, err)
@@ -309,11 +381,7 @@ func (c *ServiceClient) Run() {
 				default:
 				}
 			}
-			backoff := c.retryInterval * time.Duration(failures)
-			if backoff > c.maxRetryInterval {
-				backoff = c.maxRetryInterval
-			}
-			retryTimer.Reset(backoff)
+			retryTimer.Reset(c.retryBackoff(failures, err))
 		} else {
 			if failures > 0 {
 				c.logger.Printf("[INFO] consul.sync: successfully updated services in Consul")
@@ -337,6 +405,40 @@ func (c *ServiceClient) Run() {
 	}
 }
 
+// setSyncStatus records the result of a sync for LastSyncStatus
+func (c *ServiceClient) setSyncStatus(err error) {
+	c.syncStatusLock.Lock()
+	defer c.syncStatusLock.Unlock()
+	c.lastSyncErr = err
+	if err == nil {
+		c.lastSyncSuccess = time.Now()
+	}
+}
+
+// LastSyncStatus returns when services and checks were last successfully
+// synced with Consul and the error of the most recent sync if it failed. The
+// time is zero if no sync has succeeded yet.
+func (c *ServiceClient) LastSyncStatus() (time.Time, error) {
+	c.syncStatusLock.RLock()
+	defer c.syncStatusLock.RUnlock()
+	return c.lastSyncSuccess, c.lastSyncErr
+}
+
+// retryBackoff returns how long to wait before retrying a failed sync. The
+// wait grows with the number of consecutive failures up to the max retry
+// interval. Permission errors won't resolve until the ACL token's policy is
+// changed, so they're retried at the longer ACL retry interval.
+func (c *ServiceClient) retryBackoff(failures int, err error) time.Duration {
+	if isPermissionDenied(err) {
+		return c.aclRetryInterval
+	}
+	backoff := c.retryInterval * time.Duration(failures)
+	if backoff > c.maxRetryInterval {
+		backoff = c.maxRetryInterval
+	}
+	return backoff
+}
+
 // commit operations unless already shutting down.
 func (c *ServiceClient) commit(ops *operations) {
 	select {
@@ -356,10 +458,17 @@ func (c *ServiceClient) merge(ops *operations) {
 	for _, s := range ops.scripts {
 		c.scripts[s.id] = s
 	}
+	inGracePeriod := c.inDeregisterGracePeriod()
 	for _, sid := range ops.deregServices {
 		delete(c.services, sid)
+		if inGracePeriod {
+			c.explicitDeregServices[sid] = struct{}{}
+		}
 	}
 	for _, cid := range ops.deregChecks {
+		if inGracePeriod {
+			c.explicitDeregChecks[cid] = struct{}{}
+		}
 		if script, ok := c.runningScripts[cid]; ok {
 			script.cancel()
 			delete(c.scripts, cid)
@@ -372,10 +481,23 @@ func (c *ServiceClient) merge(ops *operations) {
 	metrics.SetGauge([]string{"client", "consul", "script_checks"}, float32(len(c.runningScripts)))
 }
 
+// inDeregisterGracePeriod returns true if unknown Nomad services and checks
+// should be left registered in Consul.
+func (c *ServiceClient) inDeregisterGracePeriod() bool {
+	return time.Now().Before(c.deregisterGraceExpiry)
+}
+
 // sync enqueued operations.
 func (c *ServiceClient) sync() error {
 	sreg, creg, sdereg, cdereg := 0, 0, 0, 0
 
+	inGracePeriod := c.inDeregisterGracePeriod()
+	if !inGracePeriod && (len(c.explicitDeregServices) > 0 || len(c.explicitDeregChecks) > 0) {
+		// Every unknown service and check is removed from now on
+		c.explicitDeregServices = make(map[string]struct{})
+		c.explicitDeregChecks = make(map[string]struct{})
+	}
+
 	consulServices, err := c.client.Services()
 	if err != nil {
 		metrics.IncrCounter([]string{"client", "consul", "sync_failure"}, 1)
@@ -398,6 +520,11 @@ func (c *ServiceClient) sync() error {
 			// Not managed by Nomad, skip
 			continue
 		}
+		if _, ok := c.explicitDeregServices[id]; inGracePeriod && !ok {
+			// May belong to an allocation that hasn't been restored
+			// yet, skip
+			continue
+		}
 
 		// Unknown Nomad managed service; kill
 		if err := c.client.ServiceDeregister(id); err != nil {
@@ -409,6 +536,7 @@ func (c *ServiceClient) sync() error {
 			metrics.IncrCounter([]string{"client", "consul", "sync_failure"}, 1)
 			return err
 		}
+		delete(c.explicitDeregServices, id)
 		sdereg++
 		metrics.IncrCounter([]string{"client", "consul", "service_deregistrations"}, 1)
 	}
@@ -435,6 +563,11 @@ func (c *ServiceClient) sync() error {
 			// Service not managed by Nomad, skip
 			continue
 		}
+		if _, ok := c.explicitDeregChecks[id]; inGracePeriod && !ok {
+			// May belong to an allocation that hasn't been restored
+			// yet, skip
+			continue
+		}
 
 		// Unknown Nomad managed check; remove
 		if err := c.client.CheckDeregister(id); err != nil {
@@ -446,6 +579,7 @@ func (c *ServiceClient) sync() error {
 			metrics.IncrCounter([]string{"client", "consul", "sync_failure"}, 1)
 			return err
 		}
+		delete(c.explicitDeregChecks, id)
 		cdereg++
 		metrics.IncrCounter([]string{"client", "consul", "check_deregistrations"}, 1)
 	}
@@ -636,8 +770,12 @@ func (c *ServiceClient) checkRegs(ops *operations, allocID, serviceID string, se
 			if exec == nil {
 				return nil, fmt.Errorf("driver doesn't support script checks")
 			}
-			ops.scripts = append(ops.scripts, newScriptCheck(
-				allocID, task.Name, checkID, check, exec, c.client, c.logger, c.shutdownCh))
+			script := newScriptCheck(
+				allocID, task.Name, checkID, check, exec, c.client, c.logger, c.shutdownCh)
+			if c.scriptOutputLimit > 0 {
+				script.maxOutput = c.scriptOutputLimit
+			}
+			ops.scripts = append(ops.scripts, script)
 
 			// Skip getAddress for script checks
 			checkReg, err := createCheckReg(serviceID, checkID, check, "", 0)
@@ -1035,6 +1173,10 @@ func createCheckReg(serviceID, checkID string, check *structs.ServiceCheck, host
 	chkReg.Status = check.InitialStatus
 	chkReg.Timeout = check.Timeout.String()
 	chkReg.Interval = check.Interval.String()
+	chkReg.Notes = check.Notes
+	if check.DeregisterCriticalServiceAfter > 0 {
+		chkReg.DeregisterCriticalServiceAfter = check.DeregisterCriticalServiceAfter.String()
+	}
 
 	// Require an address for http or tcp checks
 	if port == 0 && check.RequiresPort() {
@@ -1060,7 +1202,9 @@ func createCheckReg(serviceID, checkID string, check *structs.ServiceCheck, host
 		}
 		url := base.ResolveReference(relative)
 		chkReg.HTTP = url.String()
-		chkReg.Method = check.Method
+		// Methods are validated case insensitively but Consul sends them
+		// uses them as is and HTTP methods are case sensitive
+		chkReg.Method = strings.ToUpper(check.Method)
 		chkReg.Header = check.Header
 	case structs.ServiceCheckTCP:
 		chkReg.TCP = net.JoinHostPort(host, strconv.Itoa(port))
@@ -1081,6 +1225,18 @@ func isNomadService(id string) bool {
 	return strings.HasPrefix(id, nomadTaskPrefix) || isOldNomadService(id)
 }
 
+// isPermissionDenied returns true if the error is Consul rejecting a request
+// because the ACL token lacks permission. The Consul API only returns errors
+// as strings, so it matches on the 403 status code or the "Permission denied"
+// message in the error.
+func isPermissionDenied(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := err.Error()
+	return strings.Contains(msg, "Unexpected response code: 403") || strings.Contains(msg, "Permission denied")
+}
+
 // isOldNomadService returns true if the ID matches an old pattern managed by
 // Nomad.
 //
@@ -117,7 +117,7 @@ func TestConsul_Integration(t *testing.T) {
 					Type:     "script",
 					Command:  "/bin/true",
 					Interval: 10 * time.Second,
-					Timeout:  10 * time.Second,
+					Timeout:  5 * time.Second,
 				},
 			},
 		},
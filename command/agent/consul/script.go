@@ -3,14 +3,34 @@ package consul
 import (
 	"context"
 	"log"
+	"strings"
 	"time"
 
 	metrics "github.com/armon/go-metrics"
 	"github.com/hashicorp/consul/api"
 	"github.com/hashicorp/nomad/client/driver"
+	dstructs "github.com/hashicorp/nomad/client/driver/structs"
 	"github.com/hashicorp/nomad/nomad/structs"
 )
 
+// defaultMaxOutput is the default number of bytes of script output reported to
+// Consul. Drivers capture at most this much output, keeping the end of it.
+const defaultMaxOutput = dstructs.CheckBufSize
+
+// truncatedOutputMarker is prepended to script output that exceeded maxOutput
+const truncatedOutputMarker = "... (output truncated)\n"
+
+// consulMaxOutput is the number of bytes of check output kept by the Consul
+// agent. Longer output has its end, and so the recent results, cut off.
+const consulMaxOutput = 4 * 1024
+
+// defaultMaxHistory is the default number of recent results reported along
+// with the output of a script check
+const defaultMaxHistory = 5
+
+// historyHeader separates a script check's output from its recent results
+const historyHeader = "\n\nRecent results:\n"
+
 // heartbeater is the subset of consul agent functionality needed by script
 // checks to heartbeat
 type heartbeater interface {
@@ -44,6 +64,17 @@ type scriptCheck struct {
 	// lastCheckOk is true if the last check was ok; otherwise false
 	lastCheckOk bool
 
+	// maxOutput is the number of bytes of script output reported. As with
+	// the output captured by drivers, the end of longer output is kept and
+	// a truncation marker is prepended.
+	maxOutput int
+
+	// history holds a line for each of the most recent results, newest
+	// first, so the output shows the check's recent history. It holds at
+	// most maxHistory lines.
+	history    []string
+	maxHistory int
+
 	logger     *log.Logger
 	shutdownCh <-chan struct{}
 }
@@ -62,6 +93,8 @@ func newScriptCheck(allocID, taskName, checkID string, check *structs.ServiceChe
 		exec:        exec,
 		agent:       agent,
 		lastCheckOk: true, // start logging on first failure
+		maxOutput:   defaultMaxOutput,
+		maxHistory:  defaultMaxHistory,
 		logger:      logger,
 		shutdownCh:  shutdownCh,
 	}
@@ -128,10 +161,11 @@ func (s *scriptCheck) run() *scriptHandle {
 				state = api.HealthCritical
 				outputMsg = err.Error()
 			} else {
-				outputMsg = string(output)
+				outputMsg = s.truncateOutput(output)
 			}
 
 			// Actually heartbeat the check
+			outputMsg = s.appendHistory(outputMsg, state, time.Now())
 			err = s.agent.UpdateTTL(s.id, outputMsg, state)
 			select {
 			case <-ctx.Done():
@@ -167,3 +201,39 @@ func (s *scriptCheck) run() *scriptHandle {
 	}()
 	return &scriptHandle{cancel: cancel, exitCh: exitCh}
 }
+
+// truncateOutput returns the script output as a string, truncated to its last
+// maxOutput bytes with a marker prepended if it was longer.
+func (s *scriptCheck) truncateOutput(output []byte) string {
+	if s.maxOutput <= 0 || len(output) <= s.maxOutput {
+		return string(output)
+	}
+	return truncatedOutputMarker + string(output[len(output)-s.maxOutput:])
+}
+
+// appendHistory records a result in the check's history and returns the
+// output with the recent results appended. Including the time of each result
+// means the output is fresh after heartbeats lapse, e.g. while a task is
+// paused. The output is truncated to its end so that the recent results fit
+// within what Consul keeps.
+func (s *scriptCheck) appendHistory(output, state string, now time.Time) string {
+	if s.maxHistory <= 0 {
+		return output
+	}
+
+	line := now.UTC().Format(time.RFC3339) + " " + state
+	s.history = append([]string{line}, s.history...)
+	if len(s.history) > s.maxHistory {
+		s.history = s.history[:s.maxHistory]
+	}
+	history := historyHeader + strings.Join(s.history, "\n")
+
+	if len(output)+len(history) > consulMaxOutput {
+		keep := consulMaxOutput - len(history) - len(truncatedOutputMarker)
+		if keep < 0 {
+			keep = 0
+		}
+		output = truncatedOutputMarker + output[len(output)-keep:]
+	}
+	return output + history
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"strings"
 	"testing"
 	"time"
 
@@ -54,7 +55,7 @@ func TestConsulScript_Exec_Cancel(t *testing.T) {
 	serviceCheck := structs.ServiceCheck{
 		Name:     "sleeper",
 		Interval: time.Hour,
-		Timeout:  time.Hour,
+		Timeout:  time.Minute,
 	}
 	exec := newBlockingScriptExec()
 
@@ -99,6 +100,12 @@ func newFakeHeartbeater() *fakeHeartbeater {
 	return &fakeHeartbeater{updates: make(chan execStatus)}
 }
 
+// scriptOutput returns the script's output from the output of a heartbeat,
+// dropping the recent results.
+func scriptOutput(output string) string {
+	return strings.SplitN(output, historyHeader, 2)[0]
+}
+
 // TestConsulScript_Exec_Timeout asserts a script will be killed when the
 // timeout is reached.
 func TestConsulScript_Exec_Timeout(t *testing.T) {
@@ -170,8 +177,8 @@ func TestConsulScript_Exec_TimeoutCritical(t *testing.T) {
 		if update.status != api.HealthCritical {
 			t.Errorf("expected %q due to timeout but received %q", api.HealthCritical, update)
 		}
-		if update.output != context.DeadlineExceeded.Error() {
-			t.Errorf("expected output=%q but found: %q", context.DeadlineExceeded.Error(), update.output)
+		if output := scriptOutput(update.output); output != context.DeadlineExceeded.Error() {
+			t.Errorf("expected output=%q but found: %q", context.DeadlineExceeded.Error(), output)
 		}
 	case <-time.After(3 * time.Second):
 		t.Fatalf("timed out waiting for script check to timeout")
@@ -256,8 +263,8 @@ func TestConsulScript_Exec_Codes(t *testing.T) {
 				if err != nil {
 					expectedOutput = err.Error()
 				}
-				if update.output != expectedOutput {
-					t.Errorf("expected output=%q but found: %q", expectedOutput, update.output)
+				if output := scriptOutput(update.output); output != expectedOutput {
+					t.Errorf("expected output=%q but found: %q", expectedOutput, output)
 				}
 			case <-time.After(3 * time.Second):
 				t.Fatalf("timed out waiting for script check to exec")
@@ -278,3 +285,88 @@ func TestConsulScript_Exec_Codes(t *testing.T) {
 	t.Run("Error-2", run(2, err, api.HealthCritical))
 	t.Run("Error-9000", run(9000, err, api.HealthCritical))
 }
+
+// outputExec is a fake ScriptExecutor that returns the given output.
+type outputExec struct {
+	output []byte
+}
+
+func (o outputExec) Exec(context.Context, string, []string) ([]byte, int, error) {
+	return o.output, 0, nil
+}
+
+// TestConsulScript_Exec_TruncateOutput asserts script output longer than the
+// check's limit is truncated to its end before being reported.
+func TestConsulScript_Exec_TruncateOutput(t *testing.T) {
+	t.Parallel()
+	serviceCheck := structs.ServiceCheck{
+		Name:     "chatty",
+		Interval: time.Hour,
+		Timeout:  3 * time.Second,
+	}
+
+	hb := newFakeHeartbeater()
+	shutdown := make(chan struct{})
+	exec := outputExec{output: []byte(strings.Repeat("x", 95) + "done!")}
+	check := newScriptCheck("allocid", "testtask", "checkid", &serviceCheck, exec, hb, testLogger(), shutdown)
+	check.maxOutput = 10
+	handle := check.run()
+	defer handle.cancel()
+
+	select {
+	case update := <-hb.updates:
+		expected := truncatedOutputMarker + "xxxxxdone!"
+		if output := scriptOutput(update.output); output != expected {
+			t.Errorf("expected output=%q but found: %q", expected, output)
+		}
+		if update.status != api.HealthPassing {
+			t.Errorf("expected %q but received %q", api.HealthPassing, update.status)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatalf("timed out waiting for script check to exec")
+	}
+}
+
+// TestConsulScript_Exec_History asserts heartbeats include the most recent
+// results, newest first, capped at the check's history size.
+func TestConsulScript_Exec_History(t *testing.T) {
+	t.Parallel()
+	check := newScriptCheck("allocid", "testtask", "checkid", &structs.ServiceCheck{}, nil, nil, testLogger(), nil)
+	check.maxHistory = 2
+
+	start := time.Date(2018, 1, 2, 15, 4, 5, 0, time.UTC)
+	check.appendHistory("ok", api.HealthPassing, start)
+	check.appendHistory("ok", api.HealthWarning, start.Add(time.Minute))
+	output := check.appendHistory("resumed", api.HealthPassing, start.Add(time.Hour))
+
+	expected := "resumed" + historyHeader +
+		"2018-01-02T16:04:05Z passing\n" +
+		"2018-01-02T15:05:05Z warning"
+	if output != expected {
+		t.Fatalf("expected output=%q but found: %q", expected, output)
+	}
+}
+
+// TestConsulScript_Exec_HistoryFits asserts the recent results are kept within
+// the output size Consul keeps by truncating long output.
+func TestConsulScript_Exec_HistoryFits(t *testing.T) {
+	t.Parallel()
+	check := newScriptCheck("allocid", "testtask", "checkid", &structs.ServiceCheck{}, nil, nil, testLogger(), nil)
+
+	now := time.Date(2018, 1, 2, 15, 4, 5, 0, time.UTC)
+	output := check.appendHistory(strings.Repeat("x", consulMaxOutput)+"done!", api.HealthPassing, now)
+	if n := len(output); n > consulMaxOutput {
+		t.Fatalf("expected at most %d bytes of output but found %d", consulMaxOutput, n)
+	}
+
+	parts := strings.SplitN(output, historyHeader, 2)
+	if len(parts) != 2 {
+		t.Fatalf("expected output to contain the recent results: %q", output)
+	}
+	if !strings.HasPrefix(parts[0], truncatedOutputMarker) || !strings.HasSuffix(parts[0], "done!") {
+		t.Errorf("expected end of output with truncation marker but found: %q", parts[0])
+	}
+	if expected := "2018-01-02T15:04:05Z passing"; parts[1] != expected {
+		t.Errorf("expected recent results=%q but found: %q", expected, parts[1])
+	}
+}
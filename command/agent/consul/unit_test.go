@@ -8,6 +8,7 @@ import (
 	"os"
 	"reflect"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -15,6 +16,7 @@ import (
 	"github.com/hashicorp/consul/api"
 	cstructs "github.com/hashicorp/nomad/client/structs"
 	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/hashicorp/nomad/testutil"
 	"github.com/kr/pretty"
 	"github.com/stretchr/testify/assert"
 )
@@ -228,7 +230,7 @@ func TestConsul_ChangePorts(t *testing.T) {
 		{
 			Name:      "c1",
 			Type:      "tcp",
-			Interval:  time.Second,
+			Interval:  2 * time.Second,
 			Timeout:   time.Second,
 			PortLabel: "x",
 		},
@@ -243,7 +245,7 @@ func TestConsul_ChangePorts(t *testing.T) {
 			Type:      "http",
 			Protocol:  "http",
 			Path:      "/",
-			Interval:  time.Second,
+			Interval:  2 * time.Second,
 			Timeout:   time.Second,
 			PortLabel: "y",
 		},
@@ -317,7 +319,7 @@ func TestConsul_ChangePorts(t *testing.T) {
 		{
 			Name:      "c1",
 			Type:      "tcp",
-			Interval:  time.Second,
+			Interval:  2 * time.Second,
 			Timeout:   time.Second,
 			PortLabel: "x",
 		},
@@ -332,7 +334,7 @@ func TestConsul_ChangePorts(t *testing.T) {
 			Type:     "http",
 			Protocol: "http",
 			Path:     "/",
-			Interval: time.Second,
+			Interval: 2 * time.Second,
 			Timeout:  time.Second,
 			// Removed PortLabel; should default to service's (y)
 		},
@@ -409,7 +411,7 @@ func TestConsul_ChangeChecks(t *testing.T) {
 		{
 			Name:      "c1",
 			Type:      "tcp",
-			Interval:  time.Second,
+			Interval:  3 * time.Second,
 			Timeout:   time.Second,
 			PortLabel: "x",
 			CheckRestart: &structs.CheckRestart{
@@ -491,7 +493,7 @@ func TestConsul_ChangeChecks(t *testing.T) {
 			Name:      "c2",
 			Type:      "http",
 			Path:      "/",
-			Interval:  time.Second,
+			Interval:  2 * time.Second,
 			Timeout:   time.Second,
 			PortLabel: "x",
 		},
@@ -605,7 +607,7 @@ func TestConsul_ChangeChecks(t *testing.T) {
 			Name:      "c2",
 			Type:      "http",
 			Path:      "/",
-			Interval:  time.Second,
+			Interval:  2 * time.Second,
 			Timeout:   time.Second,
 			PortLabel: "x",
 		},
@@ -915,7 +917,7 @@ func TestConsul_ShutdownBlocked(t *testing.T) {
 			Command: "true",
 			// Make check block until shutdown
 			Interval:      9000 * time.Hour,
-			Timeout:       9000 * time.Hour,
+			Timeout:       10 * time.Second,
 			InitialStatus: "warning",
 		},
 	}
@@ -1024,13 +1026,13 @@ func TestConsul_CancelScript(t *testing.T) {
 			Name:     "scriptcheckDel",
 			Type:     "script",
 			Interval: 9000 * time.Hour,
-			Timeout:  9000 * time.Hour,
+			Timeout:  10 * time.Second,
 		},
 		{
 			Name:     "scriptcheckKeep",
 			Type:     "script",
 			Interval: 9000 * time.Hour,
-			Timeout:  9000 * time.Hour,
+			Timeout:  10 * time.Second,
 		},
 	}
 
@@ -1067,7 +1069,7 @@ func TestConsul_CancelScript(t *testing.T) {
 			Name:     "scriptcheckKeep",
 			Type:     "script",
 			Interval: 9000 * time.Hour,
-			Timeout:  9000 * time.Hour,
+			Timeout:  10 * time.Second,
 		},
 	}
 
@@ -1102,6 +1104,43 @@ func TestConsul_CancelScript(t *testing.T) {
 	}
 }
 
+// TestConsul_ScriptOutputLimit asserts script checks use the client's output
+// limit.
+func TestConsul_ScriptOutputLimit(t *testing.T) {
+	ctx := setupFake()
+	ctx.ServiceClient.SetScriptOutputLimit(10)
+	ctx.Task.Services[0].Checks = []*structs.ServiceCheck{
+		{
+			Name:     "scriptcheck",
+			Type:     "script",
+			Interval: 9000 * time.Hour,
+			Timeout:  10 * time.Second,
+		},
+	}
+
+	if err := ctx.ServiceClient.RegisterTask("allocid", ctx.Task, ctx.Restarter, ctx, nil); err != nil {
+		t.Fatalf("unexpected error registering task: %v", err)
+	}
+
+	if err := ctx.syncOnce(); err != nil {
+		t.Fatalf("unexpected error syncing task: %v", err)
+	}
+
+	if n := len(ctx.ServiceClient.scripts); n != 1 {
+		t.Fatalf("expected 1 script but found %d", n)
+	}
+	for _, script := range ctx.ServiceClient.scripts {
+		if script.maxOutput != 10 {
+			t.Errorf("expected output limit of 10 but found %d", script.maxOutput)
+		}
+	}
+
+	// Don't leak goroutines
+	for _, scriptHandle := range ctx.ServiceClient.runningScripts {
+		scriptHandle.cancel()
+	}
+}
+
 // TestConsul_DriverNetwork_AutoUse asserts that if a driver network has
 // auto-use set then services should advertise it unless explicitly set to
 // host. Checks should always use host.
@@ -1117,13 +1156,13 @@ func TestConsul_DriverNetwork_AutoUse(t *testing.T) {
 				{
 					Name:     "default-check-x",
 					Type:     "tcp",
-					Interval: time.Second,
+					Interval: 2 * time.Second,
 					Timeout:  time.Second,
 				},
 				{
 					Name:      "weird-y-check",
 					Type:      "http",
-					Interval:  time.Second,
+					Interval:  2 * time.Second,
 					Timeout:   time.Second,
 					PortLabel: "y",
 				},
@@ -1137,7 +1176,7 @@ func TestConsul_DriverNetwork_AutoUse(t *testing.T) {
 				{
 					Name:     "default-check-y",
 					Type:     "tcp",
-					Interval: time.Second,
+					Interval: 2 * time.Second,
 					Timeout:  time.Second,
 				},
 			},
@@ -1405,6 +1444,295 @@ func TestIsNomadService(t *testing.T) {
 	}
 }
 
+func TestIsPermissionDenied(t *testing.T) {
+	tests := []struct {
+		err    error
+		result bool
+	}{
+		{nil, false},
+		{fmt.Errorf("Unexpected response code: 403 (Permission denied)"), true},
+		{fmt.Errorf("Unexpected response code: 403 (ACL not found)"), true},
+		{fmt.Errorf("Unexpected response code: 500 (rpc error)"), false},
+	}
+
+	for _, test := range tests {
+		if actual := isPermissionDenied(test.err); actual != test.result {
+			t.Errorf("%v should be %t but found %t", test.err, test.result, actual)
+		}
+	}
+}
+
+// TestConsul_DeregisterGracePeriod asserts unknown Nomad services are left
+// registered during the grace period while explicitly removed services are
+// still deregistered.
+func TestConsul_DeregisterGracePeriod(t *testing.T) {
+	ctx := setupFake()
+
+	// A service left over from before a restart whose alloc hasn't been
+	// restored yet
+	staleID := makeTaskServiceID("stale-alloc", "stale-task", ctx.Task.Services[0])
+	if err := ctx.FakeConsul.ServiceRegister(&api.AgentServiceRegistration{ID: staleID, Name: "stale"}); err != nil {
+		t.Fatalf("unexpected error registering stale service: %v", err)
+	}
+
+	ctx.ServiceClient.SetDeregisterGracePeriod(time.Hour)
+	ctx.ServiceClient.deregisterGraceExpiry = time.Now().Add(time.Hour)
+
+	if err := ctx.ServiceClient.RegisterTask("allocid", ctx.Task, ctx.Restarter, nil, nil); err != nil {
+		t.Fatalf("unexpected error registering task: %v", err)
+	}
+	if err := ctx.syncOnce(); err != nil {
+		t.Fatalf("unexpected error syncing task: %v", err)
+	}
+	if n := len(ctx.FakeConsul.services); n != 2 {
+		t.Fatalf("expected stale and task services but found %d:\n%#v", n, ctx.FakeConsul.services)
+	}
+
+	// Registrations proceed but explicit removals are still honored
+	ctx.ServiceClient.RemoveTask("allocid", ctx.Task)
+	if err := ctx.syncOnce(); err != nil {
+		t.Fatalf("unexpected error syncing task: %v", err)
+	}
+	if n := len(ctx.FakeConsul.services); n != 1 {
+		t.Fatalf("expected only the stale service but found %d:\n%#v", n, ctx.FakeConsul.services)
+	}
+	if _, ok := ctx.FakeConsul.services[staleID]; !ok {
+		t.Fatalf("expected stale service %q to be retained", staleID)
+	}
+
+	// Once the grace period ends the stale service is reaped
+	ctx.ServiceClient.deregisterGraceExpiry = time.Now().Add(-time.Second)
+	if err := ctx.ServiceClient.sync(); err != nil {
+		t.Fatalf("unexpected error syncing: %v", err)
+	}
+	if n := len(ctx.FakeConsul.services); n != 0 {
+		t.Fatalf("expected no services but found %d:\n%#v", n, ctx.FakeConsul.services)
+	}
+}
+
+// TestConsul_DeregisterGracePeriod_Run asserts Run removes unknown Nomad
+// services once the grace period ends without further operations.
+func TestConsul_DeregisterGracePeriod_Run(t *testing.T) {
+	ctx := setupFake()
+
+	staleID := makeTaskServiceID("stale-alloc", "stale-task", ctx.Task.Services[0])
+	if err := ctx.FakeConsul.ServiceRegister(&api.AgentServiceRegistration{ID: staleID, Name: "stale"}); err != nil {
+		t.Fatalf("unexpected error registering stale service: %v", err)
+	}
+
+	ctx.ServiceClient.SetDeregisterGracePeriod(500 * time.Millisecond)
+	go ctx.ServiceClient.Run()
+	defer ctx.ServiceClient.Shutdown()
+
+	if err := ctx.ServiceClient.RegisterTask("allocid", ctx.Task, ctx.Restarter, nil, nil); err != nil {
+		t.Fatalf("unexpected error registering task: %v", err)
+	}
+
+	// The task's service is registered while the stale service is retained
+	testutil.WaitForResult(func() (bool, error) {
+		services, _ := ctx.FakeConsul.Services()
+		if n := len(services); n != 2 {
+			return false, fmt.Errorf("expected stale and task services but found %d", n)
+		}
+		return true, nil
+	}, func(err error) {
+		t.Fatalf("err: %v", err)
+	})
+
+	// The stale service is removed once the grace period ends
+	testutil.WaitForResult(func() (bool, error) {
+		services, _ := ctx.FakeConsul.Services()
+		if _, ok := services[staleID]; ok {
+			return false, fmt.Errorf("expected stale service %q to be removed", staleID)
+		}
+		if n := len(services); n != 1 {
+			return false, fmt.Errorf("expected only the task service but found %d", n)
+		}
+		return true, nil
+	}, func(err error) {
+		t.Fatalf("err: %v", err)
+	})
+}
+
+// TestConsul_RetryBackoff asserts failed syncs back off linearly up to the
+// max retry interval while permission errors back off the longer ACL retry
+// interval.
+func TestConsul_RetryBackoff(t *testing.T) {
+	ctx := setupFake()
+	c := ctx.ServiceClient
+	c.retryInterval = time.Second
+	c.maxRetryInterval = 30 * time.Second
+	c.aclRetryInterval = 5 * time.Minute
+
+	err := fmt.Errorf("Unexpected response code: 500 (rpc error)")
+	if backoff := c.retryBackoff(1, err); backoff != time.Second {
+		t.Errorf("expected 1s backoff but found %s", backoff)
+	}
+	if backoff := c.retryBackoff(5, err); backoff != 5*time.Second {
+		t.Errorf("expected 5s backoff but found %s", backoff)
+	}
+	if backoff := c.retryBackoff(100, err); backoff != c.maxRetryInterval {
+		t.Errorf("expected %s backoff but found %s", c.maxRetryInterval, backoff)
+	}
+
+	aclErr := fmt.Errorf("Unexpected response code: 403 (Permission denied)")
+	if backoff := c.retryBackoff(1, aclErr); backoff != c.aclRetryInterval {
+		t.Errorf("expected %s backoff but found %s", c.aclRetryInterval, backoff)
+	}
+}
+
+// failingAgent is a MockAgent whose Services and ServiceRegister calls fail
+// while their error is set. Services calls, made once per sync, are counted.
+type failingAgent struct {
+	*MockAgent
+
+	err         error
+	registerErr error
+	calls       int
+	errMu       sync.Mutex
+}
+
+func (f *failingAgent) setErr(err error) {
+	f.errMu.Lock()
+	defer f.errMu.Unlock()
+	f.err = err
+}
+
+func (f *failingAgent) setRegisterErr(err error) {
+	f.errMu.Lock()
+	defer f.errMu.Unlock()
+	f.registerErr = err
+}
+
+func (f *failingAgent) numCalls() int {
+	f.errMu.Lock()
+	defer f.errMu.Unlock()
+	return f.calls
+}
+
+func (f *failingAgent) Services() (map[string]*api.AgentService, error) {
+	f.errMu.Lock()
+	err := f.err
+	f.calls++
+	f.errMu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	return f.MockAgent.Services()
+}
+
+func (f *failingAgent) ServiceRegister(service *api.AgentServiceRegistration) error {
+	f.errMu.Lock()
+	err := f.registerErr
+	f.errMu.Unlock()
+	if err != nil {
+		return err
+	}
+	return f.MockAgent.ServiceRegister(service)
+}
+
+// TestConsul_LastSyncStatus asserts the Run loop records a failed sync and
+// clears the error once a sync succeeds.
+func TestConsul_LastSyncStatus(t *testing.T) {
+	agent := &failingAgent{MockAgent: NewMockAgent()}
+	agent.setErr(fmt.Errorf("Unexpected response code: 500 (rpc error)"))
+	c := NewServiceClient(agent, true, testLogger())
+	c.retryInterval = 10 * time.Millisecond
+	c.maxRetryInterval = 10 * time.Millisecond
+
+	if last, err := c.LastSyncStatus(); !last.IsZero() || err != nil {
+		t.Fatalf("expected no sync status before Run but found %s %v", last, err)
+	}
+
+	go c.Run()
+	defer c.Shutdown()
+
+	if err := c.RegisterTask("allocid", testTask(), &restartRecorder{}, nil, nil); err != nil {
+		t.Fatalf("unexpected error registering task: %v", err)
+	}
+
+	testutil.WaitForResult(func() (bool, error) {
+		last, err := c.LastSyncStatus()
+		if err == nil {
+			return false, fmt.Errorf("expected a sync error")
+		}
+		if !last.IsZero() {
+			return false, fmt.Errorf("expected no successful sync but found %s", last)
+		}
+		return true, nil
+	}, func(err error) {
+		t.Fatalf("err: %v", err)
+	})
+
+	start := time.Now()
+	agent.setErr(nil)
+	testutil.WaitForResult(func() (bool, error) {
+		last, err := c.LastSyncStatus()
+		if err != nil {
+			return false, fmt.Errorf("expected sync error to be cleared but found: %v", err)
+		}
+		if last.Before(start) {
+			return false, fmt.Errorf("expected a successful sync after %s but found %s", start, last)
+		}
+		return true, nil
+	}, func(err error) {
+		t.Fatalf("err: %v", err)
+	})
+}
+
+// TestConsul_ACLRetry asserts the Run loop retries syncs failing on ACL
+// permission errors at the ACL retry interval rather than the normal cadence.
+func TestConsul_ACLRetry(t *testing.T) {
+	aclErr := fmt.Errorf("Unexpected response code: 403 (Permission denied)")
+	cases := []struct {
+		name  string
+		setup func(*failingAgent)
+	}{
+		{
+			name:  "Services",
+			setup: func(a *failingAgent) { a.setErr(aclErr) },
+		},
+		{
+			name:  "ServiceRegister",
+			setup: func(a *failingAgent) { a.setRegisterErr(aclErr) },
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			agent := &failingAgent{MockAgent: NewMockAgent()}
+			tc.setup(agent)
+			c := NewServiceClient(agent, true, testLogger())
+			c.retryInterval = 10 * time.Millisecond
+			c.maxRetryInterval = 10 * time.Millisecond
+			c.aclRetryInterval = time.Hour
+
+			go c.Run()
+			defer c.Shutdown()
+
+			if err := c.RegisterTask("allocid", testTask(), &restartRecorder{}, nil, nil); err != nil {
+				t.Fatalf("unexpected error registering task: %v", err)
+			}
+
+			testutil.WaitForResult(func() (bool, error) {
+				if _, err := c.LastSyncStatus(); !isPermissionDenied(err) {
+					return false, fmt.Errorf("expected a permission error but found: %v", err)
+				}
+				return true, nil
+			}, func(err error) {
+				t.Fatalf("err: %v", err)
+			})
+
+			// Other errors would have been retried many times by now
+			time.Sleep(100 * time.Millisecond)
+			if n := agent.numCalls(); n != 1 {
+				t.Fatalf("expected 1 sync attempt but found %d", n)
+			}
+		})
+	}
+}
+
 // TestCreateCheckReg asserts Nomad ServiceCheck structs are properly converted
 // to Consul API AgentCheckRegistrations.
 func TestCreateCheckReg(t *testing.T) {
@@ -1417,6 +1745,8 @@ func TestCreateCheckReg(t *testing.T) {
 		Header: map[string][]string{
 			"Foo": {"bar"},
 		},
+		Notes:                          "notes",
+		DeregisterCriticalServiceAfter: 10 * time.Minute,
 	}
 
 	serviceID := "testService"
@@ -1427,6 +1757,7 @@ func TestCreateCheckReg(t *testing.T) {
 	expected := &api.AgentCheckRegistration{
 		ID:        checkID,
 		Name:      "name",
+		Notes:     "notes",
 		ServiceID: serviceID,
 		AgentServiceCheck: api.AgentServiceCheck{
 			Timeout:  "0s",
@@ -1436,6 +1767,7 @@ func TestCreateCheckReg(t *testing.T) {
 			Header: map[string][]string{
 				"Foo": {"bar"},
 			},
+			DeregisterCriticalServiceAfter: "10m0s",
 		},
 	}
 
@@ -1447,6 +1779,52 @@ func TestCreateCheckReg(t *testing.T) {
 	if diff := pretty.Diff(actual, expected); len(diff) > 0 {
 		t.Fatalf("diff:\n%s\n", strings.Join(diff, "\n"))
 	}
+
+	// Methods are registered upper case
+	check.Method = "post"
+	actual, err = createCheckReg(serviceID, checkID, check, host, port)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if actual.Method != "POST" {
+		t.Fatalf("expected method %q but found %q", "POST", actual.Method)
+	}
+}
+
+// TestCreateCheckReg_TCP asserts TCP check intervals, timeouts, and initial
+// statuses are passed to Consul.
+func TestCreateCheckReg_TCP(t *testing.T) {
+	check := &structs.ServiceCheck{
+		Name:          "name",
+		Type:          "tcp",
+		Interval:      5 * time.Second,
+		Timeout:       2 * time.Second,
+		InitialStatus: api.HealthPassing,
+	}
+
+	serviceID := "testService"
+	checkID := check.Hash(serviceID)
+
+	expected := &api.AgentCheckRegistration{
+		ID:        checkID,
+		Name:      "name",
+		ServiceID: serviceID,
+		AgentServiceCheck: api.AgentServiceCheck{
+			Interval: "5s",
+			Timeout:  "2s",
+			Status:   api.HealthPassing,
+			TCP:      "localhost:41111",
+		},
+	}
+
+	actual, err := createCheckReg(serviceID, checkID, check, "localhost", 41111)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	if diff := pretty.Diff(actual, expected); len(diff) > 0 {
+		t.Fatalf("diff:\n%s\n", strings.Join(diff, "\n"))
+	}
 }
 
 // TestGetAddress asserts Nomad uses the correct ip and port for services and
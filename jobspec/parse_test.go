@@ -476,6 +476,42 @@ func TestParse(t *testing.T) {
 			},
 			false,
 		},
+		{
+			"service-check-deregister.hcl",
+			&api.Job{
+				ID:   helper.StringToPtr("check_deregister"),
+				Name: helper.StringToPtr("check_deregister"),
+				Type: helper.StringToPtr("service"),
+				TaskGroups: []*api.TaskGroup{
+					{
+						Name:  helper.StringToPtr("group"),
+						Count: helper.IntToPtr(1),
+						Tasks: []*api.Task{
+							{
+								Name: "task",
+								Services: []*api.Service{
+									{
+										PortLabel: "http",
+										Checks: []api.ServiceCheck{
+											{
+												Name:     "check-name",
+												Type:     "tcp",
+												Interval: 10 * time.Second,
+												Timeout:  2 * time.Second,
+												Notes:    "Checks the http port",
+
+												DeregisterCriticalServiceAfter: 5 * time.Minute,
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			false,
+		},
 		{
 			"service-check-bad-header.hcl",
 			nil,
@@ -0,0 +1,21 @@
+job "check_deregister" {
+    type = "service"
+    group "group" {
+        count = 1
+
+        task "task" {
+          service {
+            port = "http"
+
+            check {
+              name     = "check-name"
+              type     = "tcp"
+              interval = "10s"
+              timeout  = "2s"
+              notes    = "Checks the http port"
+              deregister_critical_service_after = "5m"
+            }
+          }
+        }
+    }
+}
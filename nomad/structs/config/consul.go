@@ -42,6 +42,15 @@ type ConsulConfig struct {
 	// Timeout is used by Consul HTTP Client
 	Timeout time.Duration `mapstructure:"timeout"`
 
+	// DeregisterGracePeriod is how long after the agent starts that Nomad
+	// services unknown to the client are left registered in Consul, giving
+	// restored allocations time to register their services again.
+	DeregisterGracePeriod time.Duration `mapstructure:"deregister_grace_period"`
+
+	// ScriptOutputLimit is the number of bytes of script check output
+	// reported to Consul. Longer output is truncated to its end.
+	ScriptOutputLimit int `mapstructure:"script_output_limit"`
+
 	// Token is used to provide a per-request ACL token. This options overrides
 	// the agent's default token
 	Token string `mapstructure:"token"`
@@ -109,6 +118,12 @@ func (a *ConsulConfig) Merge(b *ConsulConfig) *ConsulConfig {
 	if b.Timeout != 0 {
 		result.Timeout = b.Timeout
 	}
+	if b.DeregisterGracePeriod != 0 {
+		result.DeregisterGracePeriod = b.DeregisterGracePeriod
+	}
+	if b.ScriptOutputLimit != 0 {
+		result.ScriptOutputLimit = b.ScriptOutputLimit
+	}
 	if b.Token != "" {
 		result.Token = b.Token
 	}
@@ -144,6 +159,11 @@ func (a *ConsulConfig) Merge(b *ConsulConfig) *ConsulConfig {
 
 // ApiConfig returns a usable Consul config that can be passed directly to
 // hashicorp/consul/api.  NOTE: datacenter is not set
+//
+// Fields left unset fall back to the CONSUL_HTTP_* environment variables read
+// by the Consul API, matching the Consul CLI. A configured address, token or
+// auth takes precedence over the environment. Since ssl defaults to false it
+// can enable HTTPS but can't disable HTTPS enabled by CONSUL_HTTP_SSL.
 func (c *ConsulConfig) ApiConfig() (*consul.Config, error) {
 	// Get the default config from consul to reuse things like the default
 	// http.Transport.
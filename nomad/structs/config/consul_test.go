@@ -0,0 +1,85 @@
+package config
+
+import (
+	"os"
+	"testing"
+
+	consulapi "github.com/hashicorp/consul/api"
+)
+
+// setConsulEnv sets the environment variables and returns a func that restores
+// their previous values.
+func setConsulEnv(t *testing.T, env map[string]string) func() {
+	old := make(map[string]*string, len(env))
+	for k, v := range env {
+		if prev, ok := os.LookupEnv(k); ok {
+			old[k] = &prev
+		} else {
+			old[k] = nil
+		}
+		if err := os.Setenv(k, v); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+	return func() {
+		for k, v := range old {
+			if v == nil {
+				os.Unsetenv(k)
+			} else {
+				os.Setenv(k, *v)
+			}
+		}
+	}
+}
+
+func TestConsulConfig_ApiConfig_Env(t *testing.T) {
+	defer setConsulEnv(t, map[string]string{
+		consulapi.HTTPAddrEnvName:  "10.0.0.1:8501",
+		consulapi.HTTPTokenEnvName: "env-token",
+		consulapi.HTTPSSLEnvName:   "true",
+	})()
+
+	c := DefaultConsulConfig()
+	conf, err := c.ApiConfig()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if conf.Address != "10.0.0.1:8501" {
+		t.Fatalf("bad address: %q", conf.Address)
+	}
+	if conf.Token != "env-token" {
+		t.Fatalf("bad token: %q", conf.Token)
+	}
+	if conf.Scheme != "https" {
+		t.Fatalf("bad scheme: %q", conf.Scheme)
+	}
+
+	client, err := consulapi.NewClient(conf)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if client == nil {
+		t.Fatalf("expected a client")
+	}
+}
+
+func TestConsulConfig_ApiConfig_EnvOverridden(t *testing.T) {
+	defer setConsulEnv(t, map[string]string{
+		consulapi.HTTPAddrEnvName:  "10.0.0.1:8501",
+		consulapi.HTTPTokenEnvName: "env-token",
+	})()
+
+	c := DefaultConsulConfig()
+	c.Addr = "127.0.0.1:9500"
+	c.Token = "config-token"
+	conf, err := c.ApiConfig()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if conf.Address != "127.0.0.1:9500" {
+		t.Fatalf("bad address: %q", conf.Address)
+	}
+	if conf.Token != "config-token" {
+		t.Fatalf("bad token: %q", conf.Token)
+	}
+}
@@ -3309,6 +3309,12 @@ func TestTaskDiff(t *testing.T) {
 										Old:  "",
 										New:  "foo",
 									},
+									{
+										Type: DiffTypeAdded,
+										Name: "DeregisterCriticalServiceAfter",
+										Old:  "",
+										New:  "0",
+									},
 									{
 										Type: DiffTypeAdded,
 										Name: "Interval",
@@ -3363,6 +3369,12 @@ func TestTaskDiff(t *testing.T) {
 										Old:  "foo",
 										New:  "",
 									},
+									{
+										Type: DiffTypeDeleted,
+										Name: "DeregisterCriticalServiceAfter",
+										Old:  "0",
+										New:  "",
+									},
 									{
 										Type: DiffTypeDeleted,
 										Name: "Interval",
@@ -3519,6 +3531,12 @@ func TestTaskDiff(t *testing.T) {
 										Old:  "foo",
 										New:  "foo",
 									},
+									{
+										Type: DiffTypeNone,
+										Name: "DeregisterCriticalServiceAfter",
+										Old:  "0",
+										New:  "0",
+									},
 									{
 										Type: DiffTypeEdited,
 										Name: "InitialStatus",
@@ -3543,6 +3561,12 @@ func TestTaskDiff(t *testing.T) {
 										Old:  "foo",
 										New:  "foo",
 									},
+									{
+										Type: DiffTypeNone,
+										Name: "Notes",
+										Old:  "",
+										New:  "",
+									},
 									{
 										Type: DiffTypeNone,
 										Name: "Path",
@@ -11,7 +11,9 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"math"
 	"net"
+	"net/http"
 	"net/url"
 	"os"
 	"path/filepath"
@@ -2894,6 +2896,11 @@ type ServiceCheck struct {
 	Method        string              // HTTP Method to use (GET by default)
 	Header        map[string][]string // HTTP Headers for Consul to set when making HTTP checks
 	CheckRestart  *CheckRestart       // If and when a task should be restarted based on checks
+	Notes         string              // Free-form human readable notes attached to the check
+
+	// DeregisterCriticalServiceAfter is how long the check may remain
+	// critical before Consul deregisters its service. Zero disables it.
+	DeregisterCriticalServiceAfter time.Duration
 }
 
 func (sc *ServiceCheck) Copy() *ServiceCheck {
@@ -2947,6 +2954,13 @@ func (sc *ServiceCheck) validate() error {
 			return fmt.Errorf("http type must have a relative http path")
 		}
 
+		switch strings.ToUpper(sc.Method) {
+		case "", http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut,
+			http.MethodPatch, http.MethodDelete, http.MethodOptions, http.MethodTrace:
+		default:
+			return fmt.Errorf("http check method %q is not a valid http method", sc.Method)
+		}
+
 	case ServiceCheckScript:
 		if sc.Command == "" {
 			return fmt.Errorf("script type must have a valid script path")
@@ -2968,6 +2982,11 @@ func (sc *ServiceCheck) validate() error {
 		return fmt.Errorf("timeout (%v) is lower than required minimum timeout %v", sc.Timeout, minCheckInterval)
 	}
 
+	// A check that times out after its next run is due never completes
+	if sc.Timeout >= sc.Interval {
+		return fmt.Errorf("timeout (%v) must be lower than interval (%v)", sc.Timeout, sc.Interval)
+	}
+
 	// Validate InitialStatus
 	switch sc.InitialStatus {
 	case "":
@@ -2979,6 +2998,11 @@ func (sc *ServiceCheck) validate() error {
 
 	}
 
+	// Validate DeregisterCriticalServiceAfter
+	if sc.DeregisterCriticalServiceAfter < 0 {
+		return fmt.Errorf("deregister_critical_service_after (%v) must be positive", sc.DeregisterCriticalServiceAfter)
+	}
+
 	// Validate AddressMode
 	switch sc.AddressMode {
 	case "", AddressModeHost, AddressModeDriver:
@@ -3046,6 +3070,15 @@ func (sc *ServiceCheck) Hash(serviceID string) string {
 		io.WriteString(h, sc.AddressMode)
 	}
 
+	// Only include Notes and DeregisterCriticalServiceAfter if set to
+	// maintain ID stability with existing checks
+	if len(sc.Notes) > 0 {
+		io.WriteString(h, sc.Notes)
+	}
+	if sc.DeregisterCriticalServiceAfter > 0 {
+		io.WriteString(h, sc.DeregisterCriticalServiceAfter.String())
+	}
+
 	return fmt.Sprintf("%x", h.Sum(nil))
 }
 
@@ -3139,6 +3172,17 @@ func (s *Service) Validate() error {
 		mErr.Errors = append(mErr.Errors, fmt.Errorf("service address_mode must be %q, %q, or %q; not %q", AddressModeAuto, AddressModeHost, AddressModeDriver, s.AddressMode))
 	}
 
+	// Numeric port labels must be valid port numbers
+	if port, err := strconv.Atoi(s.PortLabel); err == nil && (port < 0 || port > math.MaxUint16) {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("service port %d must be between 0 and %d", port, math.MaxUint16))
+	}
+
+	for i, tag := range s.Tags {
+		if tag == "" {
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("service tag %d is empty", i))
+		}
+	}
+
 	for _, c := range s.Checks {
 		if s.PortLabel == "" && c.PortLabel == "" && c.RequiresPort() {
 			mErr.Errors = append(mErr.Errors, fmt.Errorf("check %s invalid: check requires a port but neither check nor service %+q have a port", c.Name, s.Name))
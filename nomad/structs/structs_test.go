@@ -1178,6 +1178,79 @@ func TestTask_Validate_Services(t *testing.T) {
 	}
 }
 
+func TestService_Validate(t *testing.T) {
+	cases := []struct {
+		Name    string
+		Service *Service
+		Err     string
+	}{
+		{
+			Name: "Valid",
+			Service: &Service{
+				Name:      "valid",
+				PortLabel: "http",
+				Tags:      []string{"foo", "bar"},
+			},
+		},
+		{
+			Name: "ValidNumericPort",
+			Service: &Service{
+				Name:        "valid",
+				PortLabel:   "65535",
+				AddressMode: AddressModeDriver,
+			},
+		},
+		{
+			Name: "PortTooHigh",
+			Service: &Service{
+				Name:        "invalid",
+				PortLabel:   "65536",
+				AddressMode: AddressModeDriver,
+			},
+			Err: "service port 65536 must be between",
+		},
+		{
+			Name: "NegativePort",
+			Service: &Service{
+				Name:        "invalid",
+				PortLabel:   "-1",
+				AddressMode: AddressModeDriver,
+			},
+			Err: "service port -1 must be between",
+		},
+		{
+			Name: "BadName",
+			Service: &Service{
+				Name: "_nomad-reserved",
+			},
+			Err: "service name must be valid",
+		},
+		{
+			Name: "EmptyTag",
+			Service: &Service{
+				Name: "invalid",
+				Tags: []string{"foo", ""},
+			},
+			Err: "service tag 1 is empty",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			err := c.Service.Validate()
+			if c.Err == "" {
+				if err != nil {
+					t.Fatalf("unexpected err: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), c.Err) {
+				t.Fatalf("expected error containing %q but found: %v", c.Err, err)
+			}
+		})
+	}
+}
+
 func TestTask_Validate_Service_AddressMode_Ok(t *testing.T) {
 	ephemeralDisk := DefaultEphemeralDisk()
 	getTask := func(s *Service) *Task {
@@ -1347,6 +1420,25 @@ func TestTask_Validate_Service_Check(t *testing.T) {
 		t.Fatalf("err: %v", err)
 	}
 
+	check1.Timeout = check1.Interval
+	err = check1.validate()
+	if err == nil || !strings.Contains(err.Error(), "must be lower than interval") {
+		t.Fatalf("expected a timeout validation error but received: %q", err)
+	}
+	check1.Timeout = 2 * time.Second
+
+	check1.DeregisterCriticalServiceAfter = -1 * time.Second
+	err = check1.validate()
+	if err == nil || !strings.Contains(err.Error(), "deregister_critical_service_after") {
+		t.Fatalf("expected a deregister_critical_service_after validation error but received: %q", err)
+	}
+
+	check1.DeregisterCriticalServiceAfter = time.Minute
+	err = check1.validate()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
 	check2 := ServiceCheck{
 		Name:     "check-name-2",
 		Type:     ServiceCheckHTTP,
@@ -1377,6 +1469,25 @@ func TestTask_Validate_Service_Check(t *testing.T) {
 	if !strings.Contains(err.Error(), "relative http path") {
 		t.Fatalf("err: %v", err)
 	}
+
+	check2.Path = "/foo/bar"
+	check2.Method = "POST"
+	check2.Header = map[string][]string{"Authorization": {"Bearer secret"}}
+	if err := check2.validate(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Methods are case insensitive
+	check2.Method = "post"
+	if err := check2.validate(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	check2.Method = "FETCH"
+	err = check2.validate()
+	if err == nil || !strings.Contains(err.Error(), "not a valid http method") {
+		t.Fatalf("expected a method validation error but received: %q", err)
+	}
 }
 
 // TestTask_Validate_Service_Check_AddressMode asserts that checks do not
@@ -1421,7 +1532,7 @@ func TestTask_Validate_Service_Check_AddressMode(t *testing.T) {
 					{
 						Name:     "invalid-check-1",
 						Type:     "tcp",
-						Interval: time.Second,
+						Interval: 2 * time.Second,
 						Timeout:  time.Second,
 					},
 				},
@@ -1438,7 +1549,7 @@ func TestTask_Validate_Service_Check_AddressMode(t *testing.T) {
 						Name:      "invalid-check-2",
 						Type:      "tcp",
 						PortLabel: "80",
-						Interval:  time.Second,
+						Interval:  2 * time.Second,
 						Timeout:   time.Second,
 					},
 				},
@@ -1455,7 +1566,7 @@ func TestTask_Validate_Service_Check_AddressMode(t *testing.T) {
 						Name:      "invalid-check-3",
 						Type:      "tcp",
 						PortLabel: "missing-port-label",
-						Interval:  time.Second,
+						Interval:  2 * time.Second,
 						Timeout:   time.Second,
 					},
 				},
@@ -1472,21 +1583,21 @@ func TestTask_Validate_Service_Check_AddressMode(t *testing.T) {
 						Name:     "valid-script-check",
 						Type:     "script",
 						Command:  "ok",
-						Interval: time.Second,
+						Interval: 2 * time.Second,
 						Timeout:  time.Second,
 					},
 					{
 						Name:      "valid-host-check",
 						Type:      "tcp",
 						PortLabel: "http",
-						Interval:  time.Second,
+						Interval:  2 * time.Second,
 						Timeout:   time.Second,
 					},
 					{
 						Name:        "valid-driver-check",
 						Type:        "tcp",
 						AddressMode: "driver",
-						Interval:    time.Second,
+						Interval:    2 * time.Second,
 						Timeout:     time.Second,
 					},
 				},
@@ -1500,14 +1611,14 @@ func TestTask_Validate_Service_Check_AddressMode(t *testing.T) {
 						Name:      "valid-port-label",
 						Type:      "tcp",
 						PortLabel: "http",
-						Interval:  time.Second,
+						Interval:  2 * time.Second,
 						Timeout:   time.Second,
 					},
 					{
 						Name:     "empty-is-ok",
 						Type:     "script",
 						Command:  "ok",
-						Interval: time.Second,
+						Interval: 2 * time.Second,
 						Timeout:  time.Second,
 					},
 				},
@@ -1525,7 +1636,7 @@ func TestTask_Validate_Service_Check_AddressMode(t *testing.T) {
 					{
 						Name:     "empty-is-not-ok",
 						Type:     "tcp",
-						Interval: time.Second,
+						Interval: 2 * time.Second,
 						Timeout:  time.Second,
 					},
 				},
@@ -1539,8 +1650,10 @@ func TestTask_Validate_Service_Check_AddressMode(t *testing.T) {
 		task := getTask(tc.Service)
 		t.Run(tc.Service.Name, func(t *testing.T) {
 			err := validateServices(task)
-			if err == nil && tc.ErrContains == "" {
-				// Ok!
+			if tc.ErrContains == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
 				return
 			}
 			if err == nil {